@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"airport-transfer-app/internal/amadeus"
+)
+
+// errorDetails is the template data for the error pages.
+// It carries the individual Amadeus error entries if err is an *amadeus.APIError.
+type errorDetails struct {
+	Message string
+	Status  int
+	Entries []errorEntry
+	// RetryAfter is how long Amadeus asked us to wait before trying again, such as "2 min",
+	// or "" if it did not ask
+	RetryAfter string
+}
+
+// errorEntry is an Amadeus error entry with a human-readable name of the offending field
+type errorEntry struct {
+	amadeus.ErrorEntry
+	Field string
+}
+
+// fieldLabels maps Amadeus request parameters to the labels used on our pages
+var fieldLabels = map[string]string{
+	"startDateTime":     "Date and time",
+	"startAddressLine":  "Street address",
+	"startCityName":     "City",
+	"startZipCode":      "Zip code",
+	"startCountryCode":  "Country code",
+	"startGeoCode":      "Map location",
+	"startLocationCode": "Airport",
+	"endAddressLine":    "Street address",
+	"endCityName":       "City",
+	"endZipCode":        "Zip code",
+	"endCountryCode":    "Country code",
+	"endGeoCode":        "Map location",
+	"endLocationCode":   "Airport",
+	"offerId":           "Transfer offer",
+	"passengers":        "Passengers",
+	"startConnectedSegment.transportationNumber":  "Flight number",
+	"startConnectedSegment.arrival.iataCode":      "Flight arrival airport",
+	"startConnectedSegment.arrival.localDateTime": "Flight arrival time",
+	"endConnectedSegment.transportationNumber":    "Flight number",
+	"endConnectedSegment.departure.iataCode":      "Flight departure airport",
+	"endConnectedSegment.departure.localDateTime": "Flight departure time",
+}
+
+// itemLabels names the items of list parameters, such as "stopOvers[0].duration"
+var itemLabels = map[string]string{
+	"stopOvers":                "Stop-over",
+	"passengerCharacteristics": "Passenger",
+}
+
+// itemFieldLabels maps the fields of list items to labels
+var itemFieldLabels = map[string]string{
+	"duration":    "waiting time",
+	"addressLine": "street address",
+	"cityName":    "city",
+	"zipCode":     "zip code",
+	"countryCode": "country code",
+	"geoCode":     "map location",
+	"age":         "age",
+}
+
+// describeError prepares an error for rendering. Amadeus API errors
+// are split into their entries, so that each one can be shown next to
+// the field it refers to.
+func describeError(err error) errorDetails {
+	details := errorDetails{Message: err.Error()}
+
+	// Parameters that failed validation never reached Amadeus, but
+	// are shown the same way as the errors that Amadeus reports
+	if fieldErrs := amadeus.FieldErrors(err); len(fieldErrs) > 0 {
+		for _, fe := range fieldErrs {
+			entry := amadeus.ErrorEntry{
+				Title:  fe.Reason,
+				Source: amadeus.ErrorSource{Parameter: fe.Field},
+			}
+			details.Entries = append(details.Entries, errorEntry{ErrorEntry: entry, Field: fieldLabel(fe.Field)})
+		}
+		return details
+	}
+
+	var apiErr *amadeus.APIError
+	if !errors.As(err, &apiErr) {
+		return details
+	}
+
+	details.Status = apiErr.StatusCode
+	if apiErr.RetryAfter > 0 {
+		// Whole minutes, rounded up, so that a wait of seconds is not shown as "0 min"
+		details.RetryAfter = formatDuration((apiErr.RetryAfter + time.Minute - 1).Truncate(time.Minute))
+	}
+	for _, entry := range apiErr.Errors {
+		details.Entries = append(details.Entries, errorEntry{ErrorEntry: entry, Field: fieldLabel(entry.Source.Parameter)})
+	}
+	return details
+}
+
+// fieldLabel returns the label of a request parameter, or the parameter itself if it has none
+func fieldLabel(parameter string) string {
+	if label := fieldLabels[parameter]; label != "" {
+		return label
+	}
+
+	// Label the fields of list items with the item's position, like "Stop-over 2, waiting time"
+	list, rest, _ := strings.Cut(parameter, "[")
+	index, field, _ := strings.Cut(rest, "].")
+	if n, err := strconv.Atoi(index); err == nil && itemLabels[list] != "" {
+		label := fmt.Sprintf("%s %d", itemLabels[list], n+1)
+		if itemFieldLabels[field] != "" {
+			label += ", " + itemFieldLabels[field]
+		}
+		return label
+	}
+	return parameter
+}
+
+// errorDetailsTemplate renders errorDetails as a list of error entries,
+// or as a plain message if there are none.
+// Include it with {{template "errorDetails" .}}.
+const errorDetailsTemplate = `{{define "errorDetails"}}
+	{{if .Entries}}
+	<ul>
+		{{range .Entries}}
+		<li>{{if .Field}}<strong>{{.Field}}:</strong> {{end}}{{.Title}}{{if .Detail}}: {{.Detail}}{{end}}{{if .Code}} (code {{.Code}}){{end}}</li>
+		{{end}}
+	</ul>
+	{{else}}
+	<p><strong>{{.Message}}</strong></p>
+	{{end}}
+	{{with .RetryAfter}}<p>Amadeus is busy. Please try again in {{.}}.</p>{{end}}
+{{end}}`
@@ -1,48 +1,438 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"html/template"
+	"log"
 	"net/http"
+	"strings"
+	"time"
+
+	"airport-transfer-app/internal/amadeus"
+	"airport-transfer-app/internal/bookingstore"
 )
 
-// BookingHandler receives a query URL containing offer ID, queries the Amadeus Transfer Booking API, and renders a new page with a booking confirmation
+// bookingForm holds the passenger and payment details that the booking form collects
+type bookingForm struct {
+	OfferID     string
+	Title       string
+	FirstName   string
+	LastName    string
+	PhoneNumber string
+	Email       string
+	Line        string
+	Zip         string
+	CityName    string
+	CountryCode string
+	HolderName  string
+	CardNumber  string
+	VendorCode  string
+	ExpiryDate  string
+	Cvv         string
+	Note        string
+	Flight      flightForm
+	CSRFToken   string
+	Missing     []string
+	Invalid     []string
+}
+
+// parseBookingForm reads the booking form fields from the request
+func parseBookingForm(r *http.Request) bookingForm {
+	field := func(name string) string {
+		return strings.TrimSpace(r.FormValue(name))
+	}
+	return bookingForm{
+		OfferID:     field("offerId"),
+		Title:       field("title"),
+		FirstName:   field("firstName"),
+		LastName:    field("lastName"),
+		PhoneNumber: field("phoneNumber"),
+		Email:       field("email"),
+		Line:        field("line"),
+		Zip:         field("zip"),
+		CityName:    field("cityName"),
+		CountryCode: strings.ToUpper(field("countryCode")),
+		HolderName:  field("holderName"),
+		CardNumber:  strings.ReplaceAll(field("cardNumber"), " ", ""),
+		VendorCode:  field("vendorCode"),
+		ExpiryDate:  field("expiryDate"),
+		Cvv:         field("cvv"),
+		Note:        field("note"),
+		Flight:      parseFlightForm(r),
+	}
+}
+
+// missingFields returns the labels of all required fields that are empty
+func (f bookingForm) missingFields() []string {
+	var missing []string
+	for _, field := range []struct{ label, value string }{
+		{"Title", f.Title},
+		{"First name", f.FirstName},
+		{"Last name", f.LastName},
+		{"Phone number", f.PhoneNumber},
+		{"Email", f.Email},
+		{"Street address", f.Line},
+		{"Zip code", f.Zip},
+		{"City", f.CityName},
+		{"Country code", f.CountryCode},
+		{"Card holder", f.HolderName},
+		{"Card number", f.CardNumber},
+		{"Card type", f.VendorCode},
+		{"Expiry date", f.ExpiryDate},
+		{"CVV", f.Cvv},
+	} {
+		if field.value == "" {
+			missing = append(missing, field.label)
+		}
+	}
+	return missing
+}
+
+// bookingParameters turns the form into the request body of the Transfer Booking API.
+// It fails if the details are incomplete or invalid, for example an expired card.
+func (f bookingForm) bookingParameters() (amadeus.BookingParameters, error) {
+	// The passenger validates the billing address, so its error can be ignored here
+	billingAddress, _ := amadeus.NewAddress(f.Line, f.Zip, f.CityName, f.CountryCode)
+	contacts := amadeus.Contacts{
+		PhoneNumber: f.PhoneNumber,
+		Email:       f.Email,
+	}
+	passenger, passengerErr := amadeus.NewPassenger(f.Title, f.FirstName, f.LastName, contacts, billingAddress)
+	card, cardErr := amadeus.NewCreditCard(f.CardNumber, f.HolderName, f.VendorCode, f.ExpiryDate, f.Cvv)
+	if err := errors.Join(passengerErr, cardErr); err != nil {
+		return amadeus.BookingParameters{}, err
+	}
+
+	p, err := amadeus.NewBookingParameters(amadeus.CreditCardPayment(card), passenger)
+	p.Data.Note = f.Note
+	if err != nil || !f.Flight.IsSet() {
+		return p, err
+	}
+
+	// Tell the provider about the connecting flight, so that the driver can wait for a delayed arrival
+	segment, err := f.Flight.segment()
+	if f.Flight.Arrival {
+		p.Data.StartConnectedSegment = segment
+	} else {
+		p.Data.EndConnectedSegment = segment
+	}
+	return p, err
+}
+
+// bookingFieldLabels maps the fields of the booking request to the labels of the booking form
+var bookingFieldLabels = map[string]string{
+	"firstName":            "First name",
+	"lastName":             "Last name",
+	"phoneNumber":          "Phone number",
+	"email":                "Email",
+	"line":                 "Street address",
+	"zip":                  "Zip code",
+	"cityName":             "City",
+	"countryCode":          "Country code",
+	"holderName":           "Card holder",
+	"number":               "Card number",
+	"vendorCode":           "Card type",
+	"expiryDate":           "Expiry date",
+	"cvv":                  "CVV",
+	"transportationNumber": "Flight number",
+	"localDateTime":        "Flight date and time",
+}
+
+// invalidFields describes each invalid field of the booking form, labelled as on the form
+func invalidFields(err error) []string {
+	var invalid []string
+	for _, fe := range amadeus.FieldErrors(err) {
+		label := bookingFieldLabels[fe.Field[strings.LastIndex(fe.Field, ".")+1:]]
+		if label == "" {
+			label = fe.Field
+		}
+		invalid = append(invalid, label+": "+fe.Reason)
+	}
+	return invalid
+}
+
+// BookingFormHandler receives a query URL containing offer ID and renders a form that collects the passenger and payment details for the booking
+func (a *app) BookingFormHandler(w http.ResponseWriter, r *http.Request) {
+	form := bookingForm{
+		OfferID: r.URL.Query().Get("offerId"),
+		Flight:  parseFlightForm(r),
+	}
+	if form.OfferID == "" {
+		http.Error(w, "missing offer ID", http.StatusBadRequest)
+		return
+	}
+
+	// Show the existing booking instead of the form if this browser has booked the offer already
+	sess := a.sessions.get(w, r)
+	if attempt, ok := sess.booking(form.OfferID); ok {
+		renderBookingConfirmation(w, r, attempt, true, sess.csrfToken)
+		return
+	}
+	if booking, err := a.bookings.GetByOffer(sess.owner, form.OfferID); err == nil {
+		renderBookingConfirmation(w, r, &bookingAttempt{flight: form.Flight, response: booking.Response()}, true, sess.csrfToken)
+		return
+	}
+
+	form.CSRFToken = sess.csrfToken
+	renderBookingForm(w, r, form)
+}
+
+// renderBookingForm renders the booking form, including a list of missing fields if there are any
+func renderBookingForm(w http.ResponseWriter, r *http.Request, form bookingForm) {
+	tmpl, err := template.New("bookingForm").Funcs(templateFuncs(requestLocale(r))).Parse(bookingFormTemplate + flightTemplate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, form)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// BookingHandler receives the booking form containing offer ID and passenger details, queries the Amadeus Transfer Booking API, and renders a new page with a booking confirmation
 func (a *app) BookingHandler(w http.ResponseWriter, r *http.Request) {
-	// Get the offer ID from the query string
-	offerID := r.URL.Query().Get("offerId")
+	// Booking changes state, so only accept form submissions
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Only accept the booking form that we rendered for this browser
+	// (see session.go)
+	sess := a.sessions.get(w, r)
+	if !sess.validCSRFToken(r) {
+		http.Error(w, "invalid or expired form, please go back and reload the booking form", http.StatusForbidden)
+		return
+	}
+
+	// Get the offer ID and the passenger details from the form
+	form := parseBookingForm(r)
+	form.CSRFToken = sess.csrfToken
+	if form.OfferID == "" {
+		http.Error(w, "missing offer ID", http.StatusBadRequest)
+		return
+	}
+
+	// Send the form back if anything is missing
+	form.Missing = form.missingFields()
+	if len(form.Missing) > 0 {
+		renderBookingForm(w, r, form)
+		return
+	}
+
+	// Send the form back if any details are invalid
+	params, err := form.bookingParameters()
+	if err != nil {
+		form.Invalid = invalidFields(err)
+		renderBookingForm(w, r, form)
+		return
+	}
+
+	// If the offer has been booked from this browser already, for example
+	// because the form was submitted twice or resent after a reload, show
+	// that booking instead of booking again
+	attempt, isNew := sess.startBooking(form.OfferID, form.Flight)
+	if !isNew {
+		select {
+		case <-attempt.done:
+		case <-r.Context().Done():
+			return
+		}
+		if attempt.err == nil {
+			renderBookingConfirmation(w, r, attempt, true, sess.csrfToken)
+			return
+		}
+		renderBookingError(w, attempt.err)
+		return
+	}
+
+	// The session forgets its bookings when the app restarts, but the store
+	// keeps them, so look for a stored booking of the offer too
+	booking, err := a.bookings.GetByOffer(sess.owner, form.OfferID)
+	if err == nil {
+		attempt.finish(booking.Response(), nil)
+		renderBookingConfirmation(w, r, attempt, true, sess.csrfToken)
+		return
+	}
+	if !errors.Is(err, bookingstore.ErrNotFound) {
+		// Booking blindly could book the offer twice
+		attempt.finish(amadeus.BookingResponse{}, err)
+		renderBookingError(w, err)
+		return
+	}
 
 	// Call the Amadeus Transfer Booking API
 	// (see internal/amadeus/book.go)
-	response, err := a.amadeusClient.Book(offerID)
+	// The booking goes on if the browser goes away, so that its result is
+	// known when the form is sent again.
+	response, err := a.amadeusClient.BookContext(context.WithoutCancel(r.Context()), form.OfferID, params)
+	attempt.finish(response, err)
 	if err != nil {
-		// Render the erorr nicely
-		template.Must(template.New("bookingError").Parse(bookingErrorTemplate)).Execute(w, err)
+		renderBookingError(w, err)
 		return
 	}
 
-	// Render the booking receipt template
-	tmpl, err := template.New("bookingReceipt").Parse(bookingConfirmationTemplate)
+	// Keep the booking for the "My bookings" pages. The transfer is booked
+	// even if this fails, so only log the error.
+	if err := a.bookings.Save(bookingstore.New(sess.owner, form.OfferID, response, params, time.Now())); err != nil {
+		log.Printf("booking %s: %v", response.Data.ID, err)
+	}
+
+	renderBookingConfirmation(w, r, attempt, false, sess.csrfToken)
+}
+
+// renderBookingError renders a failed booking
+func renderBookingError(w http.ResponseWriter, err error) {
+	template.Must(template.New("bookingError").Parse(bookingErrorTemplate+errorDetailsTemplate)).Execute(w, describeError(err))
+}
+
+// renderBookingConfirmation renders the booking receipt of a successful booking attempt.
+// If alreadyBooked is true, it tells the user that the offer had been booked before.
+func renderBookingConfirmation(w http.ResponseWriter, r *http.Request, attempt *bookingAttempt, alreadyBooked bool, csrfToken string) {
+	tmpl, err := template.New("bookingReceipt").Funcs(templateFuncs(requestLocale(r))).Parse(bookingConfirmationTemplate + flightTemplate + receiptTemplate + offerDetailsTemplate + receiptStyle)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	err = tmpl.Execute(w, response)
+	err = tmpl.Execute(w, struct {
+		Flight        flightForm
+		AlreadyBooked bool
+		CSRFToken     string
+		amadeus.BookingResponse
+	}{attempt.flight, alreadyBooked, csrfToken, attempt.response})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-
 }
 
+// booking form template
+// collects the passenger, billing address and payment details
+var bookingFormTemplate = `<html>
+<body>
+	<h1>Passenger Details</h1>
+	{{if .Missing}}
+	<p><strong>Please fill in all required fields:</strong></p>
+	<ul>
+		{{range .Missing}}<li>{{.}}</li>{{end}}
+	</ul>
+	{{end}}
+	{{if .Invalid}}
+	<p><strong>Please correct the following fields:</strong></p>
+	<ul>
+		{{range .Invalid}}<li>{{.}}</li>{{end}}
+	</ul>
+	{{end}}
+	<form method="post" action="/booking" onsubmit="this.querySelector('button[type=submit]').disabled = true">
+		<input type="hidden" name="offerId" value="{{.OfferID}}">
+		<input type="hidden" name="csrfToken" value="{{.CSRFToken}}">
+		{{template "flight" .Flight}}
+		{{if .Flight.IsSet}}
+		<input type="hidden" name="flightNumber" value="{{.Flight.Number}}">
+		<input type="hidden" name="flightDateTime" value="{{.Flight.DateTime}}">
+		{{if .Flight.Arrival}}
+		<input type="hidden" name="direction" value="fromAirport">
+		<input type="hidden" name="startLocationCode" value="{{.Flight.Airport}}">
+		{{else}}
+		<input type="hidden" name="direction" value="toAirport">
+		<input type="hidden" name="endLocationCode" value="{{.Flight.Airport}}">
+		{{end}}
+		{{end}}
+		<fieldset>
+			<legend>Passenger</legend>
+			<label for="title">Title:</label>
+			<select id="title" name="title">
+				<option value="MR" {{if eq .Title "MR"}}selected{{end}}>Mr</option>
+				<option value="MRS" {{if eq .Title "MRS"}}selected{{end}}>Mrs</option>
+				<option value="MS" {{if eq .Title "MS"}}selected{{end}}>Ms</option>
+			</select><br/>
+			<label for="firstName">First name:</label>
+			<input id="firstName" name="firstName" value="{{.FirstName}}" required><br/>
+			<label for="lastName">Last name:</label>
+			<input id="lastName" name="lastName" value="{{.LastName}}" required><br/>
+			<label for="phoneNumber">Phone number:</label>
+			<input id="phoneNumber" name="phoneNumber" type="tel" value="{{.PhoneNumber}}" placeholder="+33123456789" required><br/>
+			<label for="email">Email:</label>
+			<input id="email" name="email" type="email" value="{{.Email}}" required>
+		</fieldset>
+		<fieldset>
+			<legend>Billing address</legend>
+			<label for="line">Street address:</label>
+			<input id="line" name="line" value="{{.Line}}" required><br/>
+			<label for="zip">Zip code:</label>
+			<input id="zip" name="zip" value="{{.Zip}}" required><br/>
+			<label for="cityName">City:</label>
+			<input id="cityName" name="cityName" value="{{.CityName}}" required><br/>
+			<label for="countryCode">Country code:</label>
+			<input id="countryCode" name="countryCode" value="{{.CountryCode}}" maxlength="2" placeholder="FR" required>
+		</fieldset>
+		<fieldset>
+			<legend>Payment</legend>
+			<label for="holderName">Card holder:</label>
+			<input id="holderName" name="holderName" value="{{.HolderName}}" required><br/>
+			<label for="vendorCode">Card type:</label>
+			<select id="vendorCode" name="vendorCode">
+				<option value="VI" {{if eq .VendorCode "VI"}}selected{{end}}>Visa</option>
+				<option value="CA" {{if eq .VendorCode "CA"}}selected{{end}}>Mastercard</option>
+				<option value="AX" {{if eq .VendorCode "AX"}}selected{{end}}>American Express</option>
+			</select><br/>
+			<label for="cardNumber">Card number:</label>
+			<input id="cardNumber" name="cardNumber" value="{{.CardNumber}}" inputmode="numeric" autocomplete="cc-number" required><br/>
+			<label for="expiryDate">Expiry date (MMYY):</label>
+			<input id="expiryDate" name="expiryDate" value="{{.ExpiryDate}}" maxlength="4" placeholder="0928" autocomplete="cc-exp" required><br/>
+			<label for="cvv">CVV:</label>
+			<input id="cvv" name="cvv" maxlength="4" inputmode="numeric" autocomplete="cc-csc" required>
+		</fieldset>
+		<p>
+			<label for="note">Note to driver:</label><br/>
+			<textarea id="note" name="note">{{.Note}}</textarea>
+		</p>
+		<button type="submit">Book this transfer</button>
+	</form>
+	<p><a href="/">New search</a></p>
+</body>
+</html>`
+
 // booking confirmation template
 // include detail from the BookingResponse
 var bookingConfirmationTemplate = `<html>
+<head>
+	<title>Booking {{.Data.Reference}}</title>
+	{{template "receiptStyle"}}
+</head>
 <body>
+	{{if .AlreadyBooked}}
+	<h1>Already Booked</h1>
+	<p class="no-print">You have booked this transfer offer already, so it was not booked again. Here is your existing booking.</p>
+	{{else}}
 	<h1>Booking Confirmation</h1>
-	<p>Reference: {{.Data.Reference}}</p>
-	<p>Booking ID: {{.Data.ID}}</p>
+	{{end}}
+	<table class="receipt">
+		<tr><td>Reference</td><td>{{.Data.Reference}}</td></tr>
+		<tr><td>Booking ID</td><td>{{.Data.ID}}</td></tr>
+	</table>
+	{{template "flight" .Flight}}
+	{{$orderID := .Data.ID}}
+	{{range .Data.Transfers}}
+	{{template "transfer" .}}
+	<form method="post" action="/booking/cancel">
+		<input type="hidden" name="orderId" value="{{$orderID}}">
+		<input type="hidden" name="csrfToken" value="{{$.CSRFToken}}">
+		<input type="hidden" name="confirmNbr" value="{{.ConfirmNbr}}">
+		<button type="submit" onclick="return confirm('Cancel this transfer?')">Cancel this transfer</button>
+	</form>
+	{{end}}
+	{{with .Data.Passengers}}{{template "passengers" .}}{{end}}
 	<p>Thank you for travelling with us!</p>
-	<p><a href="/">New search</a></p>
+	<p class="no-print"><button onclick="window.print()">Print receipt</button></p>
+	<p class="no-print">You can find this booking again under <a href="/bookings/{{.Data.ID}}">My bookings</a>.</p>
+	<p class="no-print"><a href="/">New search</a></p>
 </body>
 </html>`
 
@@ -50,7 +440,11 @@ var bookingErrorTemplate = `<html>
 <body>
 	<h1>Booking Error</h1>
 	<p>We're sorry, but there was an error with your booking.</p>
-	<p>{{.}}</p>
+	{{if eq .Status 404}}
+	<p>The transfer offer is no longer available. Please search again.</p>
+	{{else}}
+	{{template "errorDetails" .}}
+	{{end}}
 	<p><a href="/">New search</a></p>
 </body>
 </html>`
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"airport-transfer-app/internal/amadeus"
+	"airport-transfer-app/internal/bookingstore"
+)
+
+// BookingsHandler renders the list of the bookings that this browser made
+func (a *app) BookingsHandler(w http.ResponseWriter, r *http.Request) {
+	// Get the browser's bookings from the store
+	// (see internal/bookingstore and owner in session.go)
+	bookings, err := a.bookings.List(a.sessions.get(w, r).owner)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// The total price is computed here rather than in the template, where an
+	// error would abort the page halfway. A booking whose transfers are priced
+	// in different currencies has no total.
+	type bookingRow struct {
+		bookingstore.Booking
+		Price   amadeus.Money
+		PriceOK bool
+	}
+	rows := make([]bookingRow, len(bookings))
+	for i, b := range bookings {
+		price, err := b.Price()
+		rows[i] = bookingRow{Booking: b, Price: price, PriceOK: err == nil}
+	}
+
+	tmpl, err := template.New("bookings").Funcs(templateFuncs(requestLocale(r))).Parse(bookingListTemplate + receiptTemplate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, rows)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// BookingDetailsHandler renders one stored booking. The order ID is the last part of the URL path, as in /bookings/ORDER-1.
+// Bookings that another browser made are not found.
+func (a *app) BookingDetailsHandler(w http.ResponseWriter, r *http.Request) {
+	orderID := strings.TrimPrefix(r.URL.Path, "/bookings/")
+	if orderID == "" {
+		http.Redirect(w, r, "/bookings", http.StatusFound)
+		return
+	}
+
+	sess := a.sessions.get(w, r)
+	booking, err := a.bookings.Get(sess.owner, orderID)
+	if errors.Is(err, bookingstore.ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	tmpl, err := template.New("booking").Funcs(templateFuncs(requestLocale(r))).Parse(bookingDetailsTemplate + receiptTemplate + offerDetailsTemplate + receiptStyle)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// The cancel buttons need the session's CSRF token (see session.go)
+	err = tmpl.Execute(w, struct {
+		CSRFToken string
+		bookingstore.Booking
+	}{sess.csrfToken, booking})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// bookingListTemplate lists the bookings with a link to each one
+var bookingListTemplate = `<html>
+<body>
+	<h1>My Bookings</h1>
+	{{if .}}
+	<table>
+		<tr>
+			<th>Reference</th>
+			<th>Pickup</th>
+			<th>From</th>
+			<th>To</th>
+			<th>Passenger</th>
+			<th>Price</th>
+			<th>Status</th>
+		</tr>
+		{{range .}}
+		<tr>
+			<td><a href="/bookings/{{.OrderID}}">{{.Reference}}</a></td>
+			{{with .Start}}<td>{{datetime .DateTime}}</td><td>{{template "place" .}}</td>{{else}}<td></td><td></td>{{end}}
+			<td>{{with .End}}{{template "place" .}}{{end}}</td>
+			<td>{{range $i, $p := .Passengers}}{{if eq $i 0}}{{$p.FirstName}} {{$p.LastName}}{{end}}{{end}}</td>
+			<td>{{if .PriceOK}}{{money .Price}}{{else}}see booking{{end}}</td>
+			<td>{{.Status}}</td>
+		</tr>
+		{{end}}
+	</table>
+	{{else}}
+	<p>You have no bookings yet.</p>
+	{{end}}
+	<p><a href="/">New search</a></p>
+</body>
+</html>`
+
+// bookingDetailsTemplate shows the receipt of a booking, with its transfers and passengers
+var bookingDetailsTemplate = `<html>
+<head>
+	<title>Booking {{.Reference}}</title>
+	{{template "receiptStyle"}}
+</head>
+<body>
+	<h1>Booking {{.Reference}}</h1>
+	<table class="receipt">
+		<tr><td>Reference</td><td>{{.Reference}}</td></tr>
+		<tr><td>Booking ID</td><td>{{.OrderID}}</td></tr>
+		<tr><td>Booked on</td><td>{{.CreatedAt.Format "2 Jan 2006, 15:04 MST"}}</td></tr>
+		<tr><td>Status</td><td>{{.Status}}</td></tr>
+	</table>
+	{{with .StartConnectedSegment}}<p>Connecting flight {{.TransportationNumber}}{{with .Arrival}}, arriving at {{.IataCode}} on {{datetime .LocalDateTime}}{{end}}</p>{{end}}
+	{{with .EndConnectedSegment}}<p>Connecting flight {{.TransportationNumber}}{{with .Departure}}, departing from {{.IataCode}} on {{datetime .LocalDateTime}}{{end}}</p>{{end}}
+	{{$orderID := .OrderID}}
+	{{range .Transfers}}
+	{{template "transfer" .}}
+	{{if ne .Status "CANCELLED"}}
+	<form method="post" action="/booking/cancel">
+		<input type="hidden" name="orderId" value="{{$orderID}}">
+		<input type="hidden" name="confirmNbr" value="{{.ConfirmNbr}}">
+		<input type="hidden" name="csrfToken" value="{{$.CSRFToken}}">
+		<button type="submit" onclick="return confirm('Cancel this transfer?')">Cancel this transfer</button>
+	</form>
+	{{end}}
+	{{end}}
+	{{with .Passengers}}{{template "passengers" .}}{{end}}
+	<p class="no-print"><button onclick="window.print()">Print receipt</button></p>
+	<p class="no-print"><a href="/bookings">My bookings</a> | <a href="/">New search</a></p>
+</body>
+</html>`
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+
+	"airport-transfer-app/internal/bookingstore"
+)
+
+// CancelHandler receives a form containing order ID and confirmation number, calls the Amadeus Transfer Management API to cancel the transfer, and renders a new page with the cancellation status
+func (a *app) CancelHandler(w http.ResponseWriter, r *http.Request) {
+	// Cancelling changes state, so only accept form submissions
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Only accept the cancel buttons of our own confirmation pages
+	// (see session.go)
+	sess := a.sessions.get(w, r)
+	if !sess.validCSRFToken(r) {
+		http.Error(w, "invalid or expired form, please go back and reload the confirmation page", http.StatusForbidden)
+		return
+	}
+
+	// Get the order ID and the confirmation number from the form
+	orderID := r.FormValue("orderId")
+	confirmNbr := r.FormValue("confirmNbr")
+
+	// Only cancel transfers of bookings that this browser made
+	// (see internal/bookingstore)
+	booking, err := a.bookings.Get(sess.owner, orderID)
+	if errors.Is(err, bookingstore.ErrNotFound) || err == nil && !hasTransfer(booking, confirmNbr) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// Call the Amadeus Transfer Management API
+	// (see internal/amadeus/cancel.go)
+	response, err := a.amadeusClient.CancelContext(r.Context(), orderID, confirmNbr)
+	if err != nil {
+		template.Must(template.New("cancellationError").Parse(cancellationErrorTemplate+errorDetailsTemplate)).Execute(w, describeError(err))
+		return
+	}
+
+	// Record the new status of the stored booking
+	err = a.bookings.UpdateStatus(sess.owner, orderID, confirmNbr, response.Data.ReservationStatus)
+	if err != nil {
+		log.Printf("booking %s: %v", orderID, err)
+	}
+
+	// Render the cancellation template
+	tmpl, err := template.New("cancellation").Parse(cancellationTemplate)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	err = tmpl.Execute(w, response)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// hasTransfer reports whether the booking has a transfer with the given confirmation number
+func hasTransfer(b bookingstore.Booking, confirmNbr string) bool {
+	for _, t := range b.Transfers {
+		if t.ConfirmNbr == confirmNbr {
+			return true
+		}
+	}
+	return false
+}
+
+var cancellationTemplate = `<html>
+<body>
+	<h1>Transfer Cancelled</h1>
+	<p>Confirmation number: {{.Data.ConfirmNbr}}</p>
+	<p>Reservation status: {{.Data.ReservationStatus}}</p>
+	<p><a href="/bookings">My bookings</a> | <a href="/">New search</a></p>
+</body>
+</html>`
+
+var cancellationErrorTemplate = `<html>
+<body>
+	<h1>Cancellation Error</h1>
+	<p>We're sorry, but the transfer could not be cancelled.</p>
+	{{template "errorDetails" .}}
+	<p><a href="/">New search</a></p>
+</body>
+</html>`
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"airport-transfer-app/internal/amadeus"
+)
+
+// flightForm is the flight that connects to a transfer: the flight that
+// arrives at the airport where the transfer starts, or the flight that
+// departs from the airport where it ends. Number is empty if the traveller
+// did not enter a flight.
+type flightForm struct {
+	Number   string
+	Airport  string
+	Arrival  bool
+	DateTime amadeus.LocalDateTime
+}
+
+// parseFlightForm reads the connecting flight from the query or form
+// parameters of the request. Both the search and the booking form send it.
+func parseFlightForm(r *http.Request) flightForm {
+	f := flightForm{
+		Number:  strings.ToUpper(strings.ReplaceAll(r.FormValue("flightNumber"), " ", "")),
+		Arrival: r.FormValue("direction") == "fromAirport",
+	}
+	if f.Arrival {
+		f.Airport = r.FormValue("startLocationCode")
+	} else {
+		f.Airport = r.FormValue("endLocationCode")
+	}
+
+	// Like the start of the transfer, the flight time is the wall-clock time at the airport
+	airportZone, _ := amadeus.AirportLocation(f.Airport)
+	f.DateTime, _ = amadeus.ParseLocalDateTime(r.FormValue("flightDateTime"), airportZone)
+	return f
+}
+
+// IsSet reports whether the traveller entered a flight
+func (f flightForm) IsSet() bool {
+	return f.Number != ""
+}
+
+// segment returns the flight as the connected segment of a search or booking
+func (f flightForm) segment() (*amadeus.ConnectedSegment, error) {
+	endpoint := &amadeus.SegmentEndpoint{IataCode: f.Airport, LocalDateTime: f.DateTime}
+	var segment amadeus.ConnectedSegment
+	var err error
+	if f.Arrival {
+		segment, err = amadeus.NewFlightSegment(f.Number, nil, endpoint)
+	} else {
+		segment, err = amadeus.NewFlightSegment(f.Number, endpoint, nil)
+	}
+	return &segment, err
+}
+
+// values returns the flight as the parameters that parseFlightForm reads
+func (f flightForm) values() url.Values {
+	v := url.Values{}
+	if !f.IsSet() {
+		return v
+	}
+	v.Set("flightNumber", f.Number)
+	v.Set("flightDateTime", f.DateTime.String())
+	if f.Arrival {
+		v.Set("direction", "fromAirport")
+		v.Set("startLocationCode", f.Airport)
+	} else {
+		v.Set("direction", "toAirport")
+		v.Set("endLocationCode", f.Airport)
+	}
+	return v
+}
+
+// flightTemplate renders a flightForm, if it is set.
+// Include it with {{template "flight" .}}; it needs the "datetime" function of templateFuncs.
+const flightTemplate = `{{define "flight"}}
+	{{if .IsSet}}
+	<p>Connecting flight {{.Number}}, {{if .Arrival}}arriving at{{else}}departing from{{end}} {{.Airport}}{{if not .DateTime.IsZero}} on {{datetime .DateTime}}{{end}}</p>
+	{{end}}
+{{end}}`
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"airport-transfer-app/internal/amadeus"
+	"airport-transfer-app/internal/amadeus/amadeustest"
+	"airport-transfer-app/internal/bookingstore"
+)
+
+// newTestServer starts the app against the Amadeus stand-in, with an empty bookings file
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	standIn := amadeustest.NewServer()
+	t.Cleanup(standIn.Close)
+	client := standIn.Client()
+	t.Cleanup(func() { client.Close() })
+
+	bookings, err := bookingstore.NewFileStore(filepath.Join(t.TempDir(), "bookings.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &app{amadeusClient: client, sessions: newSessionStore(), bookings: bookings}
+	ts := httptest.NewServer(a.routes())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// browser is an HTTP client with its own cookies, like a browser of one traveller
+type browser struct {
+	t      *testing.T
+	server *httptest.Server
+	client *http.Client
+}
+
+func newBrowser(t *testing.T, ts *httptest.Server) *browser {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &browser{t: t, server: ts, client: &http.Client{Jar: jar}}
+}
+
+// get requests a page and returns its status code and body
+func (b *browser) get(path string) (int, string) {
+	b.t.Helper()
+	res, err := b.client.Get(b.server.URL + path)
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	return readResponse(b.t, res)
+}
+
+// post submits a form and returns the status code and body of the response
+func (b *browser) post(path string, form url.Values) (int, string) {
+	b.t.Helper()
+	res, err := b.client.PostForm(b.server.URL+path, form)
+	if err != nil {
+		b.t.Fatal(err)
+	}
+	return readResponse(b.t, res)
+}
+
+func readResponse(t *testing.T, res *http.Response) (int, string) {
+	t.Helper()
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res.StatusCode, string(body)
+}
+
+var csrfTokenPattern = regexp.MustCompile(`name="csrfToken" value="([^"]+)"`)
+
+// csrfToken opens the booking form of an offer and returns the token of its session
+func (b *browser) csrfToken(offerID string) string {
+	b.t.Helper()
+	status, body := b.get("/booking/details?offerId=" + url.QueryEscape(offerID))
+	m := csrfTokenPattern.FindStringSubmatch(body)
+	if status != http.StatusOK || m == nil {
+		b.t.Fatalf("booking form: status %d, no CSRF token in\n%s", status, body)
+	}
+	return m[1]
+}
+
+// searchPath returns a search from central Paris to CDG a month from now
+func searchPath(extra string) string {
+	q := url.Values{
+		"direction":       {"toAirport"},
+		"streetAddress":   {"Rue de Rivoli"},
+		"houseNumber":     {"10"},
+		"city":            {"Paris"},
+		"zipCode":         {"75001"},
+		"countryCode":     {"FR"},
+		"latitude":        {"48.85"},
+		"longitude":       {"2.35"},
+		"endLocationCode": {"CDG"},
+		"startDateTime":   {time.Now().AddDate(0, 1, 0).Format("2006-01-02T15:04")},
+		"adults":          {"1"},
+	}
+	return "/search?" + q.Encode() + extra
+}
+
+// bookingFormValues returns a complete booking form for an offer
+func bookingFormValues(offerID, csrfToken string) url.Values {
+	return url.Values{
+		"offerId":     {offerID},
+		"title":       {"MR"},
+		"firstName":   {"Jean"},
+		"lastName":    {"Dupont"},
+		"phoneNumber": {"+33 1 23 45 67 89"},
+		"email":       {"jean@example.com"},
+		"line":        {"Rue de Rivoli 10"},
+		"zip":         {"75001"},
+		"cityName":    {"Paris"},
+		"countryCode": {"FR"},
+		"holderName":  {"Jean Dupont"},
+		"cardNumber":  {"4111111111111111"},
+		"vendorCode":  {"VI"},
+		"expiryDate":  {time.Now().AddDate(2, 0, 0).Format("0106")},
+		"cvv":         {"123"},
+		"csrfToken":   {csrfToken},
+	}
+}
+
+var offerIDPattern = regexp.MustCompile(`bookOffer\('([^']+)'\)`)
+
+// offerIDs returns the IDs of the offers on a search result page, in order
+func offerIDs(body string) []string {
+	var ids []string
+	for _, m := range offerIDPattern.FindAllStringSubmatch(body, -1) {
+		ids = append(ids, m[1])
+	}
+	return ids
+}
+
+func TestSearchHandler(t *testing.T) {
+	ts := newTestServer(t)
+	b := newBrowser(t, ts)
+
+	status, body := b.get(searchPath(""))
+	if status != http.StatusOK {
+		t.Fatalf("status %d\n%s", status, body)
+	}
+	if got := offerIDs(body); len(got) != 3 {
+		t.Errorf("got offers %v, want 3", got)
+	}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"&sort=price", []string{"OFFER-SHARED-1", "OFFER-TAXI-1", "OFFER-PRIVATE-1"}},
+		{"&sort=price&maxPrice=60", []string{"OFFER-SHARED-1"}},
+		{"&transferType=TAXI", []string{"OFFER-TAXI-1"}},
+		// Too many decimal places to compare, so the filter is ignored
+		{"&sort=price&maxPrice=0.00000000000000000001", []string{"OFFER-SHARED-1", "OFFER-TAXI-1", "OFFER-PRIVATE-1"}},
+	}
+	for _, tt := range tests {
+		status, body := b.get(searchPath(tt.query))
+		if status != http.StatusOK {
+			t.Errorf("%s: status %d", tt.query, status)
+			continue
+		}
+		if got := offerIDs(body); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("%s: got offers %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchHandlerRejectsIncompleteSearch(t *testing.T) {
+	ts := newTestServer(t)
+	b := newBrowser(t, ts)
+
+	_, body := b.get("/search?direction=toAirport&endLocationCode=CDG")
+	if len(offerIDs(body)) > 0 {
+		t.Errorf("incomplete search shows offers:\n%s", body)
+	}
+}
+
+func TestSearchHandlerRejectsUnknownLocalTime(t *testing.T) {
+	ts := newTestServer(t)
+	b := newBrowser(t, ts)
+
+	tests := []struct {
+		name, param, value string
+		want               string
+	}{
+		// Not in the list of airport time zones
+		{"unknown airport", "endLocationCode", "MIA", "The local time at MIA is unknown."},
+		// The clocks go forward from 02:00 to 03:00 in Paris
+		{"skipped time", "startDateTime", "2027-03-28T02:30", "This time does not exist in CDG"},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(searchPath(""))
+		q := u.Query()
+		q.Set(tt.param, tt.value)
+		_, body := b.get("/search?" + q.Encode())
+		if len(offerIDs(body)) > 0 || !strings.Contains(body, tt.want) {
+			t.Errorf("%s: want no offers and %q in\n%s", tt.name, tt.want, body)
+		}
+	}
+}
+
+func TestBookingHandlerRequiresCSRFToken(t *testing.T) {
+	ts := newTestServer(t)
+	b := newBrowser(t, ts)
+
+	b.get(searchPath(""))
+	status, _ := b.post("/booking", bookingFormValues("OFFER-PRIVATE-1", "forged"))
+	if status != http.StatusForbidden {
+		t.Errorf("got status %d, want %d", status, http.StatusForbidden)
+	}
+	if status, _ := b.get("/booking"); status != http.StatusMethodNotAllowed {
+		t.Errorf("GET /booking: got status %d, want %d", status, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestBookViewAndCancel(t *testing.T) {
+	ts := newTestServer(t)
+	owner := newBrowser(t, ts)
+	other := newBrowser(t, ts)
+
+	_, body := owner.get(searchPath(""))
+	offerID := offerIDs(body)[0]
+	token := owner.csrfToken(offerID)
+
+	status, body := owner.post("/booking", bookingFormValues(offerID, token))
+	if status != http.StatusOK || !strings.Contains(body, "ORDER-1") {
+		t.Fatalf("booking: status %d\n%s", status, body)
+	}
+
+	// Sending the form again shows the same booking instead of booking again
+	_, body = owner.post("/booking", bookingFormValues(offerID, token))
+	if strings.Contains(body, "ORDER-2") || !strings.Contains(body, "ORDER-1") {
+		t.Errorf("second submission booked again:\n%s", body)
+	}
+
+	// Only the browser that booked sees the booking
+	if _, body := owner.get("/bookings"); !strings.Contains(body, "ORDER-1") {
+		t.Errorf("owner's booking list misses ORDER-1:\n%s", body)
+	}
+	if _, body := other.get("/bookings"); strings.Contains(body, "ORDER-1") {
+		t.Errorf("other browser's booking list shows ORDER-1:\n%s", body)
+	}
+	if status, _ := owner.get("/bookings/ORDER-1"); status != http.StatusOK {
+		t.Errorf("owner's booking page: got status %d, want %d", status, http.StatusOK)
+	}
+	if status, _ := other.get("/bookings/ORDER-1"); status != http.StatusNotFound {
+		t.Errorf("other browser's booking page: got status %d, want %d", status, http.StatusNotFound)
+	}
+
+	// Only the browser that booked can cancel
+	cancel := func(token string) url.Values {
+		return url.Values{"orderId": {"ORDER-1"}, "confirmNbr": {"1000001"}, "csrfToken": {token}}
+	}
+	if status, _ := other.post("/booking/cancel", cancel(other.csrfToken(offerID))); status != http.StatusNotFound {
+		t.Errorf("other browser's cancellation: got status %d, want %d", status, http.StatusNotFound)
+	}
+	if status, _ := owner.post("/booking/cancel", url.Values{"orderId": {"ORDER-1"}, "confirmNbr": {"9999999"}, "csrfToken": {token}}); status != http.StatusNotFound {
+		t.Errorf("cancellation of unknown transfer: got status %d, want %d", status, http.StatusNotFound)
+	}
+	status, body = owner.post("/booking/cancel", cancel(token))
+	if status != http.StatusOK || !strings.Contains(body, "CANCELLED") {
+		t.Fatalf("cancellation: status %d\n%s", status, body)
+	}
+
+	// The stored booking knows about the cancellation
+	if _, body := owner.get("/bookings/ORDER-1"); !strings.Contains(body, "CANCELLED") {
+		t.Errorf("booking page does not show the cancellation:\n%s", body)
+	}
+}
+
+func TestBookingIsNotRepeatedAfterRestart(t *testing.T) {
+	standIn := amadeustest.NewServer()
+	t.Cleanup(standIn.Close)
+	client := standIn.Client()
+	t.Cleanup(func() { client.Close() })
+	path := filepath.Join(t.TempDir(), "bookings.json")
+
+	// start runs the app with new sessions and the bookings file, like after a restart
+	start := func() *httptest.Server {
+		bookings, err := bookingstore.NewFileStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts := httptest.NewServer((&app{amadeusClient: client, sessions: newSessionStore(), bookings: bookings}).routes())
+		t.Cleanup(ts.Close)
+		return ts
+	}
+
+	b := newBrowser(t, start())
+	_, body := b.get(searchPath(""))
+	offerID := offerIDs(body)[0]
+	form := bookingFormValues(offerID, b.csrfToken(offerID))
+	if status, body := b.post("/booking", form); status != http.StatusOK || !strings.Contains(body, "ORDER-1") {
+		t.Fatalf("booking: status %d\n%s", status, body)
+	}
+
+	// The same browser, and so the same owner cookie, after a restart
+	b.server = start()
+	status, body := b.get("/booking/details?offerId=" + url.QueryEscape(offerID))
+	if status != http.StatusOK || !strings.Contains(body, "Already Booked") || !strings.Contains(body, "ORDER-1") {
+		t.Errorf("booking form after restart: status %d, want the existing booking\n%s", status, body)
+	}
+	form.Set("csrfToken", b.csrfToken(offerID))
+	_, body = b.post("/booking", form)
+	if !strings.Contains(body, "Already Booked") || !strings.Contains(body, "ORDER-1") {
+		t.Errorf("form sent again after restart:\n%s", body)
+	}
+	if n := standIn.Requests(amadeustest.Booking); n != 1 {
+		t.Errorf("got %d booking requests, want 1", n)
+	}
+}
+
+func TestCancellationPolicyFeeFallback(t *testing.T) {
+	tmpl := template.Must(template.New("policy").Funcs(templateFuncs("en")).Parse(offerDetailsTemplate))
+	price, err := amadeus.ParseMoney("999999999999999999", "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	offer := amadeus.Offer{
+		Quotation: amadeus.Quotation{MonetaryAmount: price},
+		CancellationRules: []amadeus.CancellationRule{
+			{FeeType: amadeus.FeeValue, FeeValue: amadeus.MustParseDecimal("10"), MetricType: amadeus.MetricHours, MetricMin: "24", MetricMax: "9999"},
+			// Too large to compute
+			{FeeType: amadeus.FeePercentage, FeeValue: amadeus.MustParseDecimal("200"), MetricType: amadeus.MetricHours, MetricMin: "0", MetricMax: "24"},
+		},
+	}
+	var out strings.Builder
+	if err := tmpl.ExecuteTemplate(&out, "cancellationPolicy", offer); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Fee €10.00", "Fee unknown, see the terms and conditions"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("no %q in\n%s", want, out.String())
+		}
+	}
+}
+
+func TestOfferCancellationFee(t *testing.T) {
+	pickup := time.Date(2027, 6, 1, 12, 0, 0, 0, time.UTC)
+	offer := amadeus.Offer{
+		Start:     amadeus.Location{DateTime: amadeus.NewLocalDateTime(pickup)},
+		Quotation: amadeus.Quotation{MonetaryAmount: mustParseMoney(t, "80.00", "EUR")},
+		CancellationRules: []amadeus.CancellationRule{
+			{FeeType: amadeus.FeeValue, FeeValue: amadeus.MustParseDecimal("0"), MetricType: amadeus.MetricDays, MetricMin: "2", MetricMax: "9999"},
+			{FeeType: amadeus.FeePercentage, FeeValue: amadeus.MustParseDecimal("50"), MetricType: amadeus.MetricHours, MetricMin: "24", MetricMax: "48"},
+			{FeeType: amadeus.FeePercentage, FeeValue: amadeus.MustParseDecimal("100"), MetricType: amadeus.MetricMinutes, MetricMin: "60", MetricMax: "1440"},
+		},
+	}
+	tests := []struct {
+		before time.Duration
+		want   string
+		wantOK bool
+	}{
+		{72 * time.Hour, "0 EUR", true},
+		{48 * time.Hour, "0 EUR", true},
+		{47 * time.Hour, "40.00 EUR", true},
+		{24 * time.Hour, "40.00 EUR", true},
+		{2 * time.Hour, "80.00 EUR", true},
+		// No rule covers the last hour
+		{30 * time.Minute, "", false},
+	}
+	for _, tt := range tests {
+		fee, ok := offerCancellationFee(offer, pickup.Add(-tt.before))
+		if ok != tt.wantOK || (ok && fee.String() != tt.want) {
+			t.Errorf("%v before pickup: got %v, %v, want %s, %v", tt.before, fee, ok, tt.want, tt.wantOK)
+		}
+	}
+
+	if got := baggageCount(amadeus.Vehicle{Baggages: []amadeus.Baggage{{Count: 2}, {Count: 1}}}); got != 3 {
+		t.Errorf("baggageCount = %d, want 3", got)
+	}
+}
+
+// mustParseMoney is like amadeus.ParseMoney but fails the test on invalid input
+func mustParseMoney(t *testing.T, amount, currency string) amadeus.Money {
+	t.Helper()
+	m, err := amadeus.ParseMoney(amount, currency)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
@@ -2,13 +2,22 @@ package main
 
 import (
 	_ "embed"
+	"html/template"
 	"net/http"
 )
 
 // embed the home page in the binary
 //
 //go:embed home.html
-var homeHTML []byte
+var homeHTML string
+
+// homePage is the data of the home page template. Search is nil on a
+// fresh page. If a search was rejected, it holds the search, so that the
+// page can restore the input, and Errors explains what to correct.
+type homePage struct {
+	Search *searchForm
+	Errors formErrors
+}
 
 // HomeHandler renders the initial search page from home.html
 func (a *app) HomeHandler(w http.ResponseWriter, r *http.Request) {
@@ -19,14 +28,21 @@ func (a *app) HomeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Set the content type to HTML
-	w.Header().Set("Content-Type", "text/html")
-	defer r.Body.Close()
+	renderHomePage(w, homePage{}, http.StatusOK)
+}
 
-	// Write the embedded HTML file content to the response writer
-	_, err := w.Write(homeHTML)
+// renderHomePage renders the search page with the given status code
+func renderHomePage(w http.ResponseWriter, page homePage, status int) {
+	tmpl, err := template.New("home").Parse(homeHTML)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+
+	// Set the content type to HTML
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(status)
+
+	// Errors after this point cannot change the status any more
+	_ = tmpl.Execute(w, page)
 }
@@ -1,5 +1,13 @@
 package amadeus
 
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
 // tokenResponse contains either a valid access token
 // or an error that occurred while fetching the token
 type tokenResponse struct {
@@ -12,15 +20,66 @@ type tokenResponse struct {
 // in the background while serving the currently valid
 // token to clients
 type Client struct {
-	baseURL     string
-	accessToken chan tokenResponse
+	baseURL    string
+	httpClient *http.Client
+	// transport and timeout are applied to a copy of httpClient after
+	// all options have run. timeoutSet tells whether timeout was set.
+	transport   http.RoundTripper
+	timeout     time.Duration
+	timeoutSet  bool
+	credentials CredentialsProvider
+	retryPolicy RetryPolicy
+	environment Environment
+	// limiter is nil if rate limiting is disabled.
+	limiter          *limiter
+	endpointLimiters map[string]*limiter
+	rateLimitSet     bool
+	accessToken      chan tokenResponse
+	invalidate       chan string
+
+	// closeCtx is cancelled, and done is closed, by Close.
+	// stopped is closed when the token manager has returned.
+	closeCtx    context.Context
+	cancelClose context.CancelFunc
+	closeOnce   sync.Once
+	done        chan struct{}
+	stopped     chan struct{}
 }
 
 // Create a new client and start the token refreshing goroutine.
-func New() *Client {
+// Without options, the client talks to the test environment and
+// reads its credentials from the environment variables.
+// Call Close to stop the goroutine when the client is no longer needed.
+func New(opts ...Option) *Client {
 	c := &Client{
-		baseURL:     "https://test.api.amadeus.com/v1",
+		baseURL:     Test.BaseURL(),
+		httpClient:  &http.Client{Timeout: defaultTimeout},
+		credentials: EnvCredentials{},
+		retryPolicy: DefaultRetryPolicy(),
 		accessToken: make(chan tokenResponse),
+		invalidate:  make(chan string),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+	c.closeCtx, c.cancelClose = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.httpClient == nil {
+		c.httpClient = &http.Client{Timeout: defaultTimeout}
+	}
+	if c.transport != nil || c.timeoutSet {
+		hc := *c.httpClient
+		if c.transport != nil {
+			hc.Transport = c.transport
+		}
+		if c.timeoutSet {
+			hc.Timeout = c.timeout
+		}
+		c.httpClient = &hc
+	}
+	if !c.rateLimitSet {
+		c.limiter = c.environment.defaultLimiter()
 	}
 	go c.refreshToken()
 	return c
@@ -54,354 +113,330 @@ type AuthErrorResponse struct {
 	Title            string `json:"title"`
 }
 
+// SearchParameters contains the request body of the Transfer Search API.
+// A transfer starts at an airport (StartLocationCode) or an address
+// (Start*), and ends at an airport (EndLocationCode) or an address (End*).
 type SearchParameters struct {
-	StartLocationCode string `json:"startLocationCode,omitempty"`
-	EndAddressLine    string `json:"endAddressLine,omitempty"`
-	EndCityName       string `json:"endCityName,omitempty"`
-	EndZipCode        string `json:"endZipCode,omitempty"`
-	EndCountryCode    string `json:"endCountryCode,omitempty"`
-	EndName           string `json:"endName,omitempty"`
-	EndGeoCode        string `json:"endGeoCode,omitempty"`
-	EndLocationCode   string `json:"endLocationCode,omitempty"`
-	StartAddressLine  string `json:"startAddressLine,omitempty"`
-	StartCityName     string `json:"startCityName,omitempty"`
-	StartZipCode      string `json:"startZipCode,omitempty"`
-	StartCountryCode  string `json:"startCountryCode,omitempty"`
-	StartName         string `json:"startName,omitempty"`
-	StartGeoCode      string `json:"startGeoCode,omitempty"`
-	TransferType      string `json:"transferType,omitempty"`
-	StartDateTime     string `json:"startDateTime,omitempty"`
-	ProviderCodes     string `json:"providerCodes,omitempty"`
-	Passengers        int    `json:"passengers,omitempty"`
-	StopOvers         []struct {
-		Duration       string `json:"duration,omitempty"`
-		SequenceNumber int    `json:"sequenceNumber,omitempty"`
-		AddressLine    string `json:"addressLine,omitempty"`
-		CountryCode    string `json:"countryCode,omitempty"`
-		CityName       string `json:"cityName,omitempty"`
-		ZipCode        string `json:"zipCode,omitempty"`
-		Name           string `json:"name,omitempty"`
-		GeoCode        string `json:"geoCode,omitempty"`
-		StateCode      string `json:"stateCode,omitempty"`
-	} `json:"stopOvers,omitempty"`
-	StartConnectedSegment struct {
-		TransportationType   string `json:"transportationType,omitempty"`
-		TransportationNumber string `json:"transportationNumber,omitempty"`
-		Departure            struct {
-			LocalDateTime string `json:"localDateTime,omitempty"`
-			IataCode      string `json:"iataCode,omitempty"`
-		} `json:"departure,omitempty"`
-		Arrival struct {
-			LocalDateTime string `json:"localDateTime,omitempty"`
-			IataCode      string `json:"iataCode,omitempty"`
-		} `json:"arrival,omitempty"`
-	} `json:"startConnectedSegment,omitempty"`
-	PassengerCharacteristics []struct {
-		PassengerTypeCode string `json:"passengerTypeCode,omitempty"`
-		Age               int    `json:"age,omitempty"`
-	} `json:"passengerCharacteristics,omitempty"`
+	StartLocationCode        string                    `json:"startLocationCode,omitempty"`
+	EndAddressLine           string                    `json:"endAddressLine,omitempty"`
+	EndCityName              string                    `json:"endCityName,omitempty"`
+	EndZipCode               string                    `json:"endZipCode,omitempty"`
+	EndCountryCode           string                    `json:"endCountryCode,omitempty"`
+	EndName                  string                    `json:"endName,omitempty"`
+	EndGeoCode               string                    `json:"endGeoCode,omitempty"`
+	EndLocationCode          string                    `json:"endLocationCode,omitempty"`
+	StartAddressLine         string                    `json:"startAddressLine,omitempty"`
+	StartCityName            string                    `json:"startCityName,omitempty"`
+	StartZipCode             string                    `json:"startZipCode,omitempty"`
+	StartCountryCode         string                    `json:"startCountryCode,omitempty"`
+	StartName                string                    `json:"startName,omitempty"`
+	StartGeoCode             string                    `json:"startGeoCode,omitempty"`
+	TransferType             TransferType              `json:"transferType,omitempty"`
+	StartDateTime            LocalDateTime             `json:"startDateTime"`
+	ProviderCodes            string                    `json:"providerCodes,omitempty"`
+	Passengers               int                       `json:"passengers,omitempty"`
+	StopOvers                []StopOver                `json:"stopOvers,omitempty"`
+	StartConnectedSegment    *ConnectedSegment         `json:"startConnectedSegment,omitempty"`
+	EndConnectedSegment      *ConnectedSegment         `json:"endConnectedSegment,omitempty"`
+	PassengerCharacteristics []PassengerCharacteristic `json:"passengerCharacteristics,omitempty"`
+}
+
+// StopOver is an intermediate stop of a transfer. Duration is the
+// waiting time at the stop in ISO 8601 format, such as "PT20M".
+type StopOver struct {
+	Duration       string `json:"duration,omitempty"`
+	SequenceNumber int    `json:"sequenceNumber,omitempty"`
+	AddressLine    string `json:"addressLine,omitempty"`
+	CountryCode    string `json:"countryCode,omitempty"`
+	CityName       string `json:"cityName,omitempty"`
+	ZipCode        string `json:"zipCode,omitempty"`
+	Name           string `json:"name,omitempty"`
+	GeoCode        string `json:"geoCode,omitempty"`
+	StateCode      string `json:"stateCode,omitempty"`
+}
+
+// ConnectedSegment is a flight or train that connects to the transfer:
+// the transfer starts when it arrives, or ends before it departs.
+type ConnectedSegment struct {
+	TransportationType   TransportationType `json:"transportationType,omitempty"`
+	TransportationNumber string             `json:"transportationNumber,omitempty"`
+	Departure            *SegmentEndpoint   `json:"departure,omitempty"`
+	Arrival              *SegmentEndpoint   `json:"arrival,omitempty"`
+}
+
+// SegmentEndpoint is the departure or arrival of a connected segment.
+// Airports are identified by IataCode, train stations by UicCode.
+type SegmentEndpoint struct {
+	UicCode       string        `json:"uicCode,omitempty"`
+	IataCode      string        `json:"iataCode,omitempty"`
+	LocalDateTime LocalDateTime `json:"localDateTime"`
+}
+
+// PassengerCharacteristic describes one passenger of a searched transfer.
+type PassengerCharacteristic struct {
+	PassengerTypeCode PassengerType `json:"passengerTypeCode,omitempty"`
+	Age               int           `json:"age,omitempty"`
 }
 
 type SearchResponse struct {
-	Data []struct {
-		ID           string `json:"id"`
-		Type         string `json:"type"`
-		TransferType string `json:"transferType"`
-		Start        struct {
-			DateTime     string `json:"dateTime"`
-			LocationCode string `json:"locationCode"`
-		} `json:"start"`
-		End struct {
-			DateTime string `json:"dateTime"`
-			Address  struct {
-				Line        string  `json:"line"`
-				Zip         string  `json:"zip"`
-				CountryCode string  `json:"countryCode"`
-				CityName    string  `json:"cityName"`
-				Latitude    float64 `json:"latitude"`
-				Longitude   float64 `json:"longitude"`
-			} `json:"address"`
-			Name string `json:"name"`
-		} `json:"end"`
-		Vehicle struct {
-			Code        string `json:"code"`
-			Category    string `json:"category"`
-			Description string `json:"description"`
-			ImageURL    string `json:"imageURL"`
-			Baggages    []struct {
-				Count int    `json:"count"`
-				Size  string `json:"size"`
-			} `json:"baggages"`
-			Seats []struct {
-				Count int `json:"count"`
-			} `json:"seats"`
-		} `json:"vehicle"`
-		ServiceProvider struct {
-			Code     string   `json:"code"`
-			Name     string   `json:"name"`
-			TermsURL string   `json:"termsUrl"`
-			LogoURL  string   `json:"logoUrl"`
-			Settings []string `json:"settings"`
-		} `json:"serviceProvider"`
-		Quotation struct {
-			MonetaryAmount string `json:"monetaryAmount"`
-			CurrencyCode   string `json:"currencyCode"`
-			Taxes          []struct {
-				MonetaryAmount string `json:"monetaryAmount"`
-			} `json:"taxes"`
-			TotalTaxes struct {
-				MonetaryAmount string `json:"monetaryAmount"`
-			} `json:"totalTaxes"`
-			Base struct {
-				MonetaryAmount string `json:"monetaryAmount"`
-			} `json:"base"`
-			Discount struct {
-				MonetaryAmount string `json:"monetaryAmount"`
-			} `json:"discount"`
-			TotalFees struct {
-				MonetaryAmount string `json:"monetaryAmount"`
-			} `json:"totalFees"`
-		} `json:"quotation"`
-		CancellationRules []struct {
-			FeeType         string `json:"feeType"`
-			FeeValue        string `json:"feeValue"`
-			CurrencyCode    string `json:"currencyCode"`
-			MetricType      string `json:"metricType"`
-			MetricMin       string `json:"metricMin"`
-			MetricMax       string `json:"metricMax"`
-			RuleDescription string `json:"ruleDescription"`
-		} `json:"cancellationRules"`
-		MethodsOfPaymentAccepted []string `json:"methodsOfPaymentAccepted"`
-		PassengerCharacteristics []struct {
-			PassengerTypeCode string `json:"passengerTypeCode"`
-			Age               int    `json:"age"`
-		} `json:"passengerCharacteristics"`
-		Converted struct {
-			MonetaryAmount string `json:"monetaryAmount"`
-			CurrencyCode   string `json:"currencyCode"`
-			Taxes          []struct {
-				MonetaryAmount string `json:"monetaryAmount"`
-			} `json:"taxes"`
-			TotalTaxes struct {
-				MonetaryAmount string `json:"monetaryAmount"`
-			} `json:"totalTaxes"`
-			Base struct {
-				MonetaryAmount string `json:"monetaryAmount"`
-			} `json:"base"`
-			Discount struct {
-				MonetaryAmount string `json:"monetaryAmount"`
-			} `json:"discount"`
-			TotalFees struct {
-				MonetaryAmount string `json:"monetaryAmount"`
-			} `json:"totalFees"`
-		} `json:"converted"`
-	} `json:"data"`
-}
-
-type SearchErrorResponse struct {
-	Errors []struct {
-		Status int    `json:"status"`
-		Code   int    `json:"code"`
-		Title  string `json:"title"`
-		Detail string `json:"detail"`
-		Source struct {
-			Parameter string `json:"parameter"`
-		} `json:"source"`
-	} `json:"errors"`
+	Data []Offer `json:"data"`
+}
+
+// Offer is a transfer offer returned by the Transfer Search API.
+type Offer struct {
+	ID                       string                    `json:"id"`
+	Type                     string                    `json:"type"`
+	TransferType             TransferType              `json:"transferType"`
+	Start                    Location                  `json:"start"`
+	End                      Location                  `json:"end"`
+	Vehicle                  Vehicle                   `json:"vehicle"`
+	ServiceProvider          ServiceProvider           `json:"serviceProvider"`
+	Quotation                Quotation                 `json:"quotation"`
+	Converted                Quotation                 `json:"converted"`
+	CancellationRules        []CancellationRule        `json:"cancellationRules"`
+	MethodsOfPaymentAccepted []PaymentMethod           `json:"methodsOfPaymentAccepted"`
+	PassengerCharacteristics []PassengerCharacteristic `json:"passengerCharacteristics"`
+}
+
+// Location is the start or end of an offered or booked transfer:
+// either an airport (LocationCode) or an address.
+type Location struct {
+	DateTime     LocalDateTime `json:"dateTime"`
+	LocationCode string        `json:"locationCode,omitempty"`
+	Address      Address       `json:"address"`
+	Name         string        `json:"name,omitempty"`
+}
+
+type Vehicle struct {
+	Code        VehicleCode     `json:"code"`
+	Category    VehicleCategory `json:"category"`
+	Description string          `json:"description"`
+	ImageURL    string          `json:"imageURL"`
+	Baggages    []Baggage       `json:"baggages"`
+	Seats       []Seat          `json:"seats"`
+}
+
+// SeatCount returns the number of passenger seats of the vehicle,
+// or 0 if the offer does not tell.
+func (v Vehicle) SeatCount() int {
+	n := 0
+	for _, s := range v.Seats {
+		n += s.Count
+	}
+	return n
+}
+
+type Baggage struct {
+	Count int    `json:"count"`
+	Size  string `json:"size"`
+}
+
+type Seat struct {
+	Count int `json:"count"`
+}
+
+type ServiceProvider struct {
+	Code     string   `json:"code"`
+	Name     string   `json:"name"`
+	TermsURL string   `json:"termsUrl"`
+	LogoURL  string   `json:"logoUrl"`
+	Settings []string `json:"settings,omitempty"`
 }
 
+// BookingParameters contains the request body of the Transfer Booking API.
+// Use NewBookingParameters to create one with validated passengers and payment.
 type BookingParameters struct {
-	Data struct {
-		Note       string `json:"note"`
-		Passengers []struct {
-			FirstName string `json:"firstName"`
-			LastName  string `json:"lastName"`
-			Title     string `json:"title"`
-			Contacts  struct {
-				PhoneNumber string `json:"phoneNumber"`
-				Email       string `json:"email"`
-			} `json:"contacts"`
-			BillingAddress struct {
-				Line        string `json:"line"`
-				Zip         string `json:"zip"`
-				CountryCode string `json:"countryCode"`
-				CityName    string `json:"cityName"`
-			} `json:"billingAddress"`
-		} `json:"passengers"`
-		Agency struct {
-			Contacts []struct {
-				Email struct {
-					Address string `json:"address"`
-				} `json:"email"`
-			} `json:"contacts"`
-		} `json:"agency"`
-		Payment struct {
-			MethodOfPayment string `json:"methodOfPayment"`
-			CreditCard      struct {
-				Number     string `json:"number"`
-				HolderName string `json:"holderName"`
-				VendorCode string `json:"vendorCode"`
-				ExpiryDate string `json:"expiryDate"`
-				Cvv        string `json:"cvv"`
-			} `json:"creditCard"`
-		} `json:"payment"`
-		ExtraServices []struct {
-			Code   string `json:"code"`
-			ItemID string `json:"itemId"`
-		} `json:"extraServices"`
-		Equipment []struct {
-			Code string `json:"code"`
-		} `json:"equipment"`
-		Corporation struct {
-			Address struct {
-				Line        string `json:"line"`
-				Zip         string `json:"zip"`
-				CountryCode string `json:"countryCode"`
-				CityName    string `json:"cityName"`
-			} `json:"address"`
-			Info struct {
-				Au string `json:"AU"`
-				Ce string `json:"CE"`
-			} `json:"info"`
-		} `json:"corporation"`
-		StartConnectedSegment struct {
-			TransportationType   string `json:"transportationType"`
-			TransportationNumber string `json:"transportationNumber"`
-			Departure            struct {
-				UicCode       string `json:"uicCode"`
-				IataCode      string `json:"iataCode"`
-				LocalDateTime string `json:"localDateTime"`
-			} `json:"departure"`
-			Arrival struct {
-				UicCode       string `json:"uicCode"`
-				IataCode      string `json:"iataCode"`
-				LocalDateTime string `json:"localDateTime"`
-			} `json:"arrival"`
-		} `json:"startConnectedSegment"`
-		EndConnectedSegment struct {
-			TransportationType   string `json:"transportationType"`
-			TransportationNumber string `json:"transportationNumber"`
-			Departure            struct {
-				UicCode       string `json:"uicCode"`
-				IataCode      string `json:"iataCode"`
-				LocalDateTime string `json:"localDateTime"`
-			} `json:"departure"`
-			Arrival struct {
-				UicCode       string `json:"uicCode"`
-				IataCode      string `json:"iataCode"`
-				LocalDateTime string `json:"localDateTime"`
-			} `json:"arrival"`
-		} `json:"endConnectedSegment"`
-	} `json:"data"`
+	Data BookingData `json:"data"`
+}
+
+// BookingData is the content of BookingParameters. Optional sections
+// are pointers, so that they are left out of the request entirely when
+// they are not set.
+type BookingData struct {
+	Note                  string            `json:"note,omitempty"`
+	Passengers            []Passenger       `json:"passengers"`
+	Agency                *Agency           `json:"agency,omitempty"`
+	Payment               Payment           `json:"payment"`
+	ExtraServices         []ExtraService    `json:"extraServices,omitempty"`
+	Equipment             []Equipment       `json:"equipment,omitempty"`
+	Corporation           *Corporation      `json:"corporation,omitempty"`
+	StartConnectedSegment *ConnectedSegment `json:"startConnectedSegment,omitempty"`
+	EndConnectedSegment   *ConnectedSegment `json:"endConnectedSegment,omitempty"`
+}
+
+// Passenger is a traveller on a booked transfer.
+// Type is only set in responses.
+type Passenger struct {
+	Type           string   `json:"type,omitempty"`
+	FirstName      string   `json:"firstName"`
+	LastName       string   `json:"lastName"`
+	Title          string   `json:"title"`
+	Contacts       Contacts `json:"contacts"`
+	BillingAddress Address  `json:"billingAddress"`
+}
+
+type Contacts struct {
+	PhoneNumber string `json:"phoneNumber"`
+	Email       string `json:"email"`
+}
+
+// Address is a postal address. Latitude and longitude are only set
+// for the start and end of offers and transfers.
+type Address struct {
+	Line        string  `json:"line"`
+	Zip         string  `json:"zip"`
+	CountryCode string  `json:"countryCode"`
+	CityName    string  `json:"cityName"`
+	Latitude    float64 `json:"latitude,omitempty"`
+	Longitude   float64 `json:"longitude,omitempty"`
+}
+
+// Payment describes how a transfer is paid. CreditCard is only
+// required if MethodOfPayment is "CREDIT_CARD".
+type Payment struct {
+	MethodOfPayment PaymentMethod `json:"methodOfPayment"`
+	CreditCard      *CreditCard   `json:"creditCard,omitempty"`
+}
+
+type CreditCard struct {
+	Number     string `json:"number"`
+	HolderName string `json:"holderName"`
+	VendorCode string `json:"vendorCode"`
+	ExpiryDate string `json:"expiryDate"`
+	Cvv        string `json:"cvv"`
+}
+
+// Agency identifies the travel agency that books the transfer.
+type Agency struct {
+	Contacts []AgencyContact `json:"contacts"`
+}
+
+type AgencyContact struct {
+	Email struct {
+		Address string `json:"address"`
+	} `json:"email"`
+}
+
+type ExtraService struct {
+	Code   string `json:"code"`
+	ItemID string `json:"itemId"`
+}
+
+type Equipment struct {
+	Code string `json:"code"`
+}
+
+// Corporation identifies the company that pays for the transfer.
+type Corporation struct {
+	Address Address         `json:"address"`
+	Info    CorporationInfo `json:"info"`
+}
+
+type CorporationInfo struct {
+	AU string `json:"AU"`
+	CE string `json:"CE"`
 }
 
 type BookingResponse struct {
-	Data struct {
-		Type       string `json:"type"`
-		Reference  string `json:"reference"`
-		ID         string `json:"id"`
-		Passengers []struct {
-			Type      string `json:"type"`
-			FirstName string `json:"firstName"`
-			LastName  string `json:"lastName"`
-			Title     string `json:"title"`
-			Contacts  struct {
-				Email       string `json:"email"`
-				PhoneNumber string `json:"phoneNumber"`
-			} `json:"contacts"`
-			BillingAddress struct {
-				Line        string `json:"line"`
-				Zip         string `json:"zip"`
-				CountryCode string `json:"countryCode"`
-				CityName    string `json:"cityName"`
-			} `json:"billingAddress"`
-		} `json:"passengers"`
-		Transfers []struct {
-			Status            string `json:"status"`
-			ConfirmNbr        string `json:"confirmNbr"`
-			Note              string `json:"note"`
-			MethodOfPayment   string `json:"methodOfPayment"`
-			OfferID           string `json:"offerId"`
-			TransferType      string `json:"transferType"`
-			CancellationRules []struct {
-				FeeType         string `json:"feeType"`
-				FeeValue        string `json:"feeValue"`
-				CurrencyCode    string `json:"currencyCode"`
-				MetricMax       string `json:"metricMax"`
-				MetricType      string `json:"metricType"`
-				MetricMin       string `json:"metricMin"`
-				RuleDescription string `json:"ruleDescription"`
-			} `json:"cancellationRules"`
-			Start struct {
-				DateTime     string `json:"dateTime"`
-				LocationCode string `json:"locationCode"`
-			} `json:"start"`
-			End struct {
-				DateTime string `json:"dateTime"`
-				Address  struct {
-					Line        string  `json:"line"`
-					Zip         string  `json:"zip"`
-					CountryCode string  `json:"countryCode"`
-					CityName    string  `json:"cityName"`
-					Latitude    float64 `json:"latitude"`
-					Longitude   float64 `json:"longitude"`
-				} `json:"address"`
-				Name string `json:"name"`
-			} `json:"end"`
-			Vehicle struct {
-				Code        string `json:"code"`
-				Category    string `json:"category"`
-				Description string `json:"description"`
-				Baggages    []struct {
-					Count int    `json:"count"`
-					Size  string `json:"size"`
-				} `json:"baggages"`
-				Seats []struct {
-					Count int `json:"count"`
-				} `json:"seats"`
-				ImageURL string `json:"imageURL"`
-			} `json:"vehicle"`
-			ServiceProvider struct {
-				Code     string `json:"code"`
-				Name     string `json:"name"`
-				TermsURL string `json:"termsUrl"`
-				LogoURL  string `json:"logoUrl"`
-			} `json:"serviceProvider"`
-			Quotation struct {
-				MonetaryAmount string `json:"monetaryAmount"`
-				CurrencyCode   string `json:"currencyCode"`
-				Taxes          []struct {
-					MonetaryAmount string `json:"monetaryAmount"`
-				} `json:"taxes"`
-				IsEstimated bool `json:"isEstimated"`
-				TotalFees   struct {
-					MonetaryAmount string `json:"monetaryAmount"`
-				} `json:"totalFees"`
-				TotalTaxes struct {
-					MonetaryAmount string `json:"monetaryAmount"`
-				} `json:"totalTaxes"`
-			} `json:"quotation"`
-			Converted struct {
-				MonetaryAmount string `json:"monetaryAmount"`
-				CurrencyCode   string `json:"currencyCode"`
-				Taxes          []struct {
-					MonetaryAmount string `json:"monetaryAmount"`
-				} `json:"taxes"`
-				IsEstimated bool `json:"isEstimated"`
-				TotalFees   struct {
-					MonetaryAmount string `json:"monetaryAmount"`
-				} `json:"totalFees"`
-				TotalTaxes struct {
-					MonetaryAmount string `json:"monetaryAmount"`
-				} `json:"totalTaxes"`
-			} `json:"converted"`
-		} `json:"transfers"`
-	} `json:"data"`
-}
-
-type BookingErrorResponse struct {
-	Errors []struct {
-		Code   int    `json:"code"`
-		Detail string `json:"detail"`
-	} `json:"errors"`
+	Data Order `json:"data"`
+}
+
+// Order is a booking confirmation of the Transfer Booking API.
+// ID and the transfers' ConfirmNbr identify a transfer for cancellation.
+type Order struct {
+	Type       string      `json:"type"`
+	Reference  string      `json:"reference"`
+	ID         string      `json:"id"`
+	Passengers []Passenger `json:"passengers"`
+	Transfers  []Transfer  `json:"transfers"`
+}
+
+// Transfer is a booked transfer within an Order.
+type Transfer struct {
+	Status            string             `json:"status"`
+	ConfirmNbr        string             `json:"confirmNbr"`
+	Note              string             `json:"note"`
+	MethodOfPayment   PaymentMethod      `json:"methodOfPayment"`
+	OfferID           string             `json:"offerId"`
+	TransferType      TransferType       `json:"transferType"`
+	Start             Location           `json:"start"`
+	End               Location           `json:"end"`
+	Vehicle           Vehicle            `json:"vehicle"`
+	ServiceProvider   ServiceProvider    `json:"serviceProvider"`
+	Quotation         Quotation          `json:"quotation"`
+	Converted         Quotation          `json:"converted"`
+	CancellationRules []CancellationRule `json:"cancellationRules"`
+}
+
+// Quotation is the price of a transfer, in the provider's currency
+// ("quotation") or converted to the requested currency ("converted").
+// All amounts are in CurrencyCode.
+type Quotation struct {
+	MonetaryAmount Money    `json:"monetaryAmount"`
+	CurrencyCode   string   `json:"currencyCode"`
+	IsEstimated    bool     `json:"isEstimated"`
+	Base           Amount   `json:"base"`
+	Discount       Amount   `json:"discount"`
+	Taxes          []Amount `json:"taxes"`
+	TotalTaxes     Amount   `json:"totalTaxes"`
+	TotalFees      Amount   `json:"totalFees"`
+}
+
+// Amount is a part of a Quotation, such as a tax.
+type Amount struct {
+	MonetaryAmount Money `json:"monetaryAmount"`
+}
+
+// UnmarshalJSON unmarshals the quotation and sets the currency of all its amounts.
+func (q *Quotation) UnmarshalJSON(b []byte) error {
+	type quotation Quotation // without this method
+	if err := json.Unmarshal(b, (*quotation)(q)); err != nil {
+		return err
+	}
+	q.MonetaryAmount.Currency = q.CurrencyCode
+	q.Base.MonetaryAmount.Currency = q.CurrencyCode
+	q.Discount.MonetaryAmount.Currency = q.CurrencyCode
+	q.TotalTaxes.MonetaryAmount.Currency = q.CurrencyCode
+	q.TotalFees.MonetaryAmount.Currency = q.CurrencyCode
+	for i := range q.Taxes {
+		q.Taxes[i].MonetaryAmount.Currency = q.CurrencyCode
+	}
+	return nil
+}
+
+// CancellationRule describes the fee for cancelling a transfer within
+// a time window before the pickup. Depending on FeeType, FeeValue is a
+// percentage of the price ("PERCENTAGE") or an amount in CurrencyCode
+// ("VALUE").
+type CancellationRule struct {
+	FeeType         FeeType    `json:"feeType"`
+	FeeValue        Decimal    `json:"feeValue"`
+	CurrencyCode    string     `json:"currencyCode"`
+	MetricType      MetricType `json:"metricType"`
+	MetricMin       string     `json:"metricMin"`
+	MetricMax       string     `json:"metricMax"`
+	RuleDescription string     `json:"ruleDescription"`
+}
+
+// Fee returns the cancellation fee for a transfer with the given price.
+// It fails only if a percentage fee is too large to compute.
+func (r CancellationRule) Fee(price Money) (Money, error) {
+	if r.FeeType == FeePercentage {
+		return price.Percent(r.FeeValue)
+	}
+	currency := r.CurrencyCode
+	if currency == "" {
+		currency = price.Currency
+	}
+	return Money{Amount: r.FeeValue, Currency: currency}, nil
+}
+
+type CancellationResponse struct {
+	Data CancellationStatus `json:"data"`
+}
+
+// CancellationStatus is the result of cancelling a transfer.
+type CancellationStatus struct {
+	ConfirmNbr        string `json:"confirmNbr"`
+	ReservationStatus string `json:"reservationStatus"`
 }
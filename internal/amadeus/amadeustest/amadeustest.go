@@ -0,0 +1,609 @@
+// Package amadeustest provides a local stand-in for the Amadeus
+// transfer APIs, for testing and offline demos.
+//
+// The stand-in serves the token, transfer search, transfer booking
+// and transfer cancellation endpoints. Offers, errors, latency and
+// token lifetimes can be scripted:
+//
+//	srv := amadeustest.NewServer()
+//	defer srv.Close()
+//	srv.Fail(amadeustest.Search, amadeustest.Failure{Status: 503})
+//	client := srv.Client()
+//	defer client.Close()
+//	res, err := client.Search(params) // retried once, then succeeds
+package amadeustest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"airport-transfer-app/internal/amadeus"
+)
+
+// The credentials that the stand-in accepts unless they are changed
+// with SetCredentials.
+const (
+	ClientID     = "amadeustest-id"
+	ClientSecret = "amadeustest-secret"
+)
+
+// Endpoint identifies one of the served APIs.
+type Endpoint string
+
+// The endpoints of the stand-in, relative to its URL.
+const (
+	Token        Endpoint = "/security/oauth2/token"
+	Search       Endpoint = "/shopping/transfer-offers"
+	Booking      Endpoint = "/ordering/transfer-orders"
+	Cancellation Endpoint = "/ordering/transfer-orders/{orderId}/transfers/cancellation"
+)
+
+// defaultRideDuration is the duration of all offered rides.
+const defaultRideDuration = 45 * time.Minute
+
+// dateTimeLayout is the Amadeus format of local date-times.
+const dateTimeLayout = "2006-01-02T15:04:05"
+
+// Failure is a scripted error response.
+type Failure struct {
+	// Status is the HTTP status code.
+	Status int
+	// Errors are the entries of the "errors" array. If empty, the
+	// stand-in sends a single entry derived from Status.
+	Errors []amadeus.ErrorEntry
+	// RetryAfter, if not zero, is sent as Retry-After header.
+	RetryAfter time.Duration
+}
+
+// Server is a running stand-in. Its methods are safe for concurrent use.
+type Server struct {
+	server *httptest.Server
+
+	mu            sync.Mutex
+	clientID      string
+	clientSecret  string
+	offers        []map[string]any
+	searched      map[string]map[string]any // offer ID -> offer as last returned by a search
+	latency       time.Duration
+	tokenLifetime time.Duration
+	tokens        map[string]time.Time
+	failures      map[Endpoint][]Failure
+	requests      map[Endpoint]int
+	orders        map[string][]string // order ID -> confirmation numbers
+	cancelled     map[string]bool     // confirmation number -> cancelled
+	nextOrder     int
+}
+
+// NewServer starts a stand-in that serves three sample offers
+// (a private sedan, a shared shuttle and a taxi) for every search.
+// Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		clientID:      ClientID,
+		clientSecret:  ClientSecret,
+		tokenLifetime: 30 * time.Minute,
+		tokens:        map[string]time.Time{},
+		failures:      map[Endpoint][]Failure{},
+		requests:      map[Endpoint]int{},
+		orders:        map[string][]string{},
+		cancelled:     map[string]bool{},
+	}
+	if err := s.setOffers([]byte(sampleOffers)); err != nil {
+		panic("amadeustest: invalid sample offers: " + err.Error())
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// URL returns the base URL of the stand-in, to be passed to amadeus.WithBaseURL.
+func (s *Server) URL() string {
+	return s.server.URL + "/v1"
+}
+
+// Close shuts down the stand-in.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns an amadeus.Client that talks to the stand-in, with
+// matching credentials, no rate limit and short retry backoffs.
+// Further options are applied after these. Call Close on the client
+// when done.
+func (s *Server) Client(opts ...amadeus.Option) *amadeus.Client {
+	s.mu.Lock()
+	id, secret := s.clientID, s.clientSecret
+	s.mu.Unlock()
+
+	retry := amadeus.DefaultRetryPolicy()
+	retry.InitialBackoff = time.Millisecond
+	retry.MaxBackoff = 10 * time.Millisecond
+
+	return amadeus.New(append([]amadeus.Option{
+		amadeus.WithBaseURL(s.URL()),
+		amadeus.WithCredentials(id, secret),
+		amadeus.WithRateLimit(0, 0),
+		amadeus.WithRetryPolicy(retry),
+	}, opts...)...)
+}
+
+// SetCredentials changes the client ID and secret that the token endpoint accepts.
+func (s *Server) SetCredentials(clientID, clientSecret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clientID, s.clientSecret = clientID, clientSecret
+}
+
+// SetOffers replaces the offers returned by the search endpoint.
+// Empty start and end locations and times of the offers are filled
+// in from each search request.
+func (s *Server) SetOffers(offers amadeus.SearchResponse) {
+	b, err := json.Marshal(offers)
+	if err != nil {
+		panic("amadeustest: SetOffers: " + err.Error())
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.setOffers(b); err != nil {
+		panic("amadeustest: SetOffers: " + err.Error())
+	}
+}
+
+// setOffers stores the offers of a search response. s.mu must be held
+// unless the server is not running yet.
+func (s *Server) setOffers(searchResponse []byte) error {
+	var res struct {
+		Data []map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(searchResponse, &res); err != nil {
+		return err
+	}
+	s.offers = res.Data
+	s.searched = map[string]map[string]any{}
+	return nil
+}
+
+// SetLatency delays every response by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// SetTokenLifetime sets the expires_in value of newly issued tokens.
+// Requests with an expired token fail with 401 Unauthorized.
+func (s *Server) SetTokenLifetime(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokenLifetime = d
+}
+
+// ExpireTokens invalidates all issued tokens immediately, as if they
+// were revoked. The next API call with any of them fails with
+// 401 Unauthorized.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = map[string]time.Time{}
+}
+
+// Fail queues a failure for the endpoint. Each request to the endpoint
+// consumes one queued failure until the queue is empty.
+func (s *Server) Fail(e Endpoint, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[e] = append(s.failures[e], f)
+}
+
+// Requests returns the number of requests the endpoint has received,
+// including failed ones.
+func (s *Server) Requests(e Endpoint) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[e]
+}
+
+// serveHTTP routes a request to the handler of its endpoint.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v1")
+
+	var e Endpoint
+	var handle func(http.ResponseWriter, *http.Request)
+	switch {
+	case path == string(Token):
+		e, handle = Token, s.serveToken
+	case path == string(Search):
+		e, handle = Search, s.serveSearch
+	case path == string(Booking):
+		e, handle = Booking, s.serveBooking
+	case strings.HasPrefix(path, string(Booking)+"/") && strings.HasSuffix(path, "/transfers/cancellation"):
+		e, handle = Cancellation, s.serveCancellation
+	default:
+		writeErrors(w, http.StatusNotFound, amadeus.ErrorEntry{Status: 404, Code: 38196, Title: "Resource not found"})
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeErrors(w, http.StatusMethodNotAllowed, amadeus.ErrorEntry{Status: 405, Code: 38197, Title: "Method not allowed"})
+		return
+	}
+
+	s.mu.Lock()
+	s.requests[e]++
+	latency := s.latency
+	var failure *Failure
+	if queue := s.failures[e]; len(queue) > 0 {
+		failure = &queue[0]
+		s.failures[e] = queue[1:]
+	}
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if failure != nil {
+		writeFailure(w, *failure)
+		return
+	}
+
+	if e != Token && !s.authorized(r) {
+		writeErrors(w, http.StatusUnauthorized, amadeus.ErrorEntry{
+			Status: 401,
+			Code:   38192,
+			Title:  "Invalid access token",
+			Detail: "The access token provided in the Authorization header is invalid",
+		})
+		return
+	}
+
+	handle(w, r)
+}
+
+// authorized reports whether the request carries a valid, unexpired token.
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiry, ok := s.tokens[token]
+	return ok && time.Now().Before(expiry)
+}
+
+// serveToken issues a token in exchange for valid client credentials.
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "client_credentials" {
+		writeJSON(w, http.StatusBadRequest, map[string]any{
+			"error":             "unsupported_grant_type",
+			"error_description": "Only client_credentials value is allowed for the body parameter grant_type",
+			"code":              38187,
+			"title":             "Invalid parameters",
+		})
+		return
+	}
+
+	s.mu.Lock()
+	valid := r.PostForm.Get("client_id") == s.clientID && r.PostForm.Get("client_secret") == s.clientSecret
+	lifetime := s.tokenLifetime
+	s.mu.Unlock()
+
+	if !valid {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{
+			"error":             "invalid_client",
+			"error_description": "Client credentials are invalid",
+			"code":              38187,
+			"title":             "Invalid parameters",
+		})
+		return
+	}
+
+	token := randomID()
+	s.mu.Lock()
+	s.tokens[token] = time.Now().Add(lifetime)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"type":             "amadeusOAuth2Token",
+		"username":         "amadeustest@example.com",
+		"application_name": "amadeustest",
+		"client_id":        r.PostForm.Get("client_id"),
+		"token_type":       "Bearer",
+		"access_token":     token,
+		"expires_in":       int(lifetime / time.Second),
+		"state":            "approved",
+		"scope":            "",
+	})
+}
+
+// serveSearch returns the scripted offers, adapted to the search request.
+func (s *Server) serveSearch(w http.ResponseWriter, r *http.Request) {
+	var search map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&search); err != nil {
+		writeErrors(w, http.StatusBadRequest, amadeus.ErrorEntry{Status: 400, Code: 477, Title: "INVALID FORMAT", Detail: err.Error()})
+		return
+	}
+
+	if errs := validateSearch(search); len(errs) > 0 {
+		writeErrors(w, http.StatusBadRequest, errs...)
+		return
+	}
+
+	s.mu.Lock()
+	offers := make([]map[string]any, 0, len(s.offers))
+	for _, offer := range s.offers {
+		offer = fillOffer(cloneJSON(offer), search)
+		if id, ok := offer["id"].(string); ok {
+			s.searched[id] = offer
+		}
+		offers = append(offers, offer)
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"data": offers})
+}
+
+// validateSearch checks the mandatory search parameters.
+func validateSearch(search map[string]any) []amadeus.ErrorEntry {
+	var errs []amadeus.ErrorEntry
+	missing := func(param string) {
+		errs = append(errs, amadeus.ErrorEntry{
+			Status: 400,
+			Code:   32171,
+			Title:  "MANDATORY DATA MISSING",
+			Detail: "Missing mandatory parameter",
+			Source: amadeus.ErrorSource{Parameter: param},
+		})
+	}
+
+	start, _ := search["startDateTime"].(string)
+	if start == "" {
+		missing("startDateTime")
+	} else if _, err := time.Parse(dateTimeLayout, start); err != nil {
+		errs = append(errs, amadeus.ErrorEntry{
+			Status: 400,
+			Code:   477,
+			Title:  "INVALID FORMAT",
+			Detail: "Expected format is YYYY-MM-DDTHH:MM:SS",
+			Source: amadeus.ErrorSource{Parameter: "startDateTime", Example: "2023-11-10T10:30:00"},
+		})
+	}
+	if search["startLocationCode"] == nil && search["startAddressLine"] == nil {
+		missing("startLocationCode")
+	}
+	if search["endLocationCode"] == nil && search["endAddressLine"] == nil {
+		missing("endLocationCode")
+	}
+	return errs
+}
+
+// fillOffer fills the start and end of an offer from the search
+// request, unless the scripted offer has them already.
+func fillOffer(offer, search map[string]any) map[string]any {
+	startTime, _ := time.Parse(dateTimeLayout, fmt.Sprint(search["startDateTime"]))
+	offer["start"] = fillLocation(offer["start"], search, "start", startTime)
+	offer["end"] = fillLocation(offer["end"], search, "end", startTime.Add(defaultRideDuration))
+	return offer
+}
+
+// fillLocation fills an offer's start or end location from the
+// search parameters with the given prefix ("start" or "end").
+func fillLocation(v any, search map[string]any, prefix string, t time.Time) map[string]any {
+	loc, _ := v.(map[string]any)
+	if loc == nil {
+		loc = map[string]any{}
+	}
+	if loc["dateTime"] == nil || loc["dateTime"] == "" {
+		loc["dateTime"] = t.Format(dateTimeLayout)
+	}
+	if code, _ := loc["locationCode"].(string); code != "" {
+		return loc
+	}
+	// Offers set with SetOffers always have an address, which is
+	// empty unless the scripted offer filled it.
+	if address, _ := loc["address"].(map[string]any); address["line"] != nil && address["line"] != "" {
+		return loc
+	}
+
+	if code, ok := search[prefix+"LocationCode"]; ok {
+		loc["locationCode"] = code
+		return loc
+	}
+
+	address := map[string]any{
+		"line":        search[prefix+"AddressLine"],
+		"zip":         search[prefix+"ZipCode"],
+		"countryCode": search[prefix+"CountryCode"],
+		"cityName":    search[prefix+"CityName"],
+	}
+	if geo, ok := search[prefix+"GeoCode"].(string); ok {
+		if lat, lng, ok := strings.Cut(geo, ","); ok {
+			address["latitude"], _ = strconv.ParseFloat(strings.TrimSpace(lat), 64)
+			address["longitude"], _ = strconv.ParseFloat(strings.TrimSpace(lng), 64)
+		}
+	}
+	loc["address"] = address
+	if name, ok := search[prefix+"Name"]; ok {
+		loc["name"] = name
+	}
+	return loc
+}
+
+// serveBooking books one of the scripted offers.
+func (s *Server) serveBooking(w http.ResponseWriter, r *http.Request) {
+	offerID := r.URL.Query().Get("offerId")
+
+	var booking struct {
+		Data struct {
+			Note       string           `json:"note"`
+			Passengers []map[string]any `json:"passengers"`
+			Payment    map[string]any   `json:"payment"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&booking); err != nil {
+		writeErrors(w, http.StatusBadRequest, amadeus.ErrorEntry{Status: 400, Code: 477, Title: "INVALID FORMAT", Detail: err.Error()})
+		return
+	}
+	if len(booking.Data.Passengers) == 0 {
+		writeErrors(w, http.StatusBadRequest, amadeus.ErrorEntry{
+			Status: 400,
+			Code:   32171,
+			Title:  "MANDATORY DATA MISSING",
+			Detail: "At least one passenger is required",
+			Source: amadeus.ErrorSource{Pointer: "/data/passengers"},
+		})
+		return
+	}
+
+	s.mu.Lock()
+	// Book the offer as the search returned it, with its start and end,
+	// or as scripted if it has not been searched for.
+	offer := s.searched[offerID]
+	if offer == nil {
+		for _, o := range s.offers {
+			if o["id"] == offerID {
+				offer = o
+				break
+			}
+		}
+	}
+	if offer != nil {
+		offer = cloneJSON(offer)
+	}
+	if offer == nil {
+		s.mu.Unlock()
+		writeErrors(w, http.StatusNotFound, amadeus.ErrorEntry{
+			Status: 404,
+			Code:   34651,
+			Title:  "OFFER NOT FOUND",
+			Detail: "The offer " + offerID + " does not exist or has expired",
+			Source: amadeus.ErrorSource{Parameter: "offerId"},
+		})
+		return
+	}
+	s.nextOrder++
+	orderID := fmt.Sprintf("ORDER-%d", s.nextOrder)
+	confirmNbr := fmt.Sprintf("%d", 1000000+s.nextOrder)
+	reference := fmt.Sprintf("REF%06d", s.nextOrder)
+	s.orders[orderID] = []string{confirmNbr}
+	s.mu.Unlock()
+
+	// The booked transfer is the offer plus the booking details.
+	transfer := offer
+	delete(transfer, "id")
+	delete(transfer, "type")
+	transfer["status"] = "CONFIRMED"
+	transfer["confirmNbr"] = confirmNbr
+	transfer["offerId"] = offerID
+	transfer["note"] = booking.Data.Note
+	transfer["methodOfPayment"] = booking.Data.Payment["methodOfPayment"]
+
+	passengers := booking.Data.Passengers
+	for _, p := range passengers {
+		p["type"] = "passenger"
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"data": map[string]any{
+			"type":       "transfer-order",
+			"id":         orderID,
+			"reference":  reference,
+			"transfers":  []map[string]any{transfer},
+			"passengers": passengers,
+		},
+	})
+}
+
+// serveCancellation cancels a booked transfer.
+func (s *Server) serveCancellation(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v1"+string(Booking)+"/")
+	orderID := strings.TrimSuffix(path, "/transfers/cancellation")
+	confirmNbr := r.URL.Query().Get("confirmNbr")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	found := false
+	for _, nbr := range s.orders[orderID] {
+		if nbr == confirmNbr {
+			found = true
+		}
+	}
+	if !found {
+		writeErrors(w, http.StatusNotFound, amadeus.ErrorEntry{
+			Status: 404,
+			Code:   34652,
+			Title:  "ORDER NOT FOUND",
+			Detail: "No transfer " + confirmNbr + " in order " + orderID,
+			Source: amadeus.ErrorSource{Parameter: "confirmNbr"},
+		})
+		return
+	}
+	if s.cancelled[confirmNbr] {
+		writeErrors(w, http.StatusBadRequest, amadeus.ErrorEntry{
+			Status: 400,
+			Code:   34653,
+			Title:  "TRANSFER ALREADY CANCELLED",
+			Source: amadeus.ErrorSource{Parameter: "confirmNbr"},
+		})
+		return
+	}
+	s.cancelled[confirmNbr] = true
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"data": map[string]any{
+			"confirmNbr":        confirmNbr,
+			"reservationStatus": "CANCELLED",
+		},
+	})
+}
+
+// writeFailure writes a scripted failure.
+func writeFailure(w http.ResponseWriter, f Failure) {
+	if f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int((f.RetryAfter+time.Second-1)/time.Second)))
+	}
+	errs := f.Errors
+	if len(errs) == 0 {
+		errs = []amadeus.ErrorEntry{{Status: f.Status, Code: 38189, Title: http.StatusText(f.Status)}}
+	}
+	writeErrors(w, f.Status, errs...)
+}
+
+// writeErrors writes an Amadeus error response.
+func writeErrors(w http.ResponseWriter, status int, errs ...amadeus.ErrorEntry) {
+	writeJSON(w, status, map[string]any{"errors": errs})
+}
+
+// writeJSON writes v as JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/vnd.amadeus+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// cloneJSON deep-copies a JSON object.
+func cloneJSON(v map[string]any) map[string]any {
+	b, _ := json.Marshal(v)
+	var c map[string]any
+	json.Unmarshal(b, &c)
+	return c
+}
+
+// randomID returns a random hex string.
+func randomID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,299 @@
+package amadeustest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"airport-transfer-app/internal/amadeus"
+	"airport-transfer-app/internal/amadeus/amadeustest"
+)
+
+// searchParams returns a search from central Paris to CDG a month from now
+func searchParams(t *testing.T) amadeus.SearchParameters {
+	t.Helper()
+	loc, ok := amadeus.AirportLocation("CDG")
+	if !ok {
+		t.Fatal("no time zone for CDG")
+	}
+	return amadeus.SearchParameters{
+		StartAddressLine: "Rue de Rivoli 10",
+		StartCityName:    "Paris",
+		StartZipCode:     "75001",
+		StartCountryCode: "FR",
+		StartGeoCode:     "48.85,2.35",
+		EndLocationCode:  "CDG",
+		StartDateTime:    amadeus.NewLocalDateTime(time.Now().AddDate(0, 1, 0).In(loc)),
+		Passengers:       1,
+	}
+}
+
+// bookingParams returns a valid booking for one passenger, paid by card
+func bookingParams(t *testing.T) amadeus.BookingParameters {
+	t.Helper()
+	address, err := amadeus.NewAddress("Rue de Rivoli 10", "75001", "Paris", "FR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	passenger, err := amadeus.NewPassenger("MR", "Jean", "Dupont",
+		amadeus.Contacts{Email: "jean@example.com", PhoneNumber: "+33 1 23 45 67 89"}, address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	card, err := amadeus.NewCreditCard("4111111111111111", "Jean Dupont", "VI", time.Now().AddDate(2, 0, 0).Format("0106"), "123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	params, err := amadeus.NewBookingParameters(amadeus.CreditCardPayment(card), passenger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return params
+}
+
+func newClient(t *testing.T, srv *amadeustest.Server, opts ...amadeus.Option) *amadeus.Client {
+	t.Helper()
+	client := srv.Client(opts...)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestSearch(t *testing.T) {
+	srv := amadeustest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv)
+
+	res, err := client.Search(searchParams(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Data) != 3 {
+		t.Fatalf("got %d offers, want 3", len(res.Data))
+	}
+	for _, o := range res.Data {
+		if o.End.LocationCode != "CDG" {
+			t.Errorf("offer %s ends at %q, want CDG", o.ID, o.End.LocationCode)
+		}
+		if o.Quotation.MonetaryAmount.Currency == "" {
+			t.Errorf("offer %s has a price without currency", o.ID)
+		}
+	}
+}
+
+func TestSearchRetriesServiceUnavailable(t *testing.T) {
+	srv := amadeustest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv)
+
+	srv.Fail(amadeustest.Search, amadeustest.Failure{Status: 503})
+	if _, err := client.Search(searchParams(t)); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Requests(amadeustest.Search); n != 2 {
+		t.Errorf("got %d search requests, want 2", n)
+	}
+}
+
+func TestSearchGivesUpAfterMaxAttempts(t *testing.T) {
+	srv := amadeustest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv)
+
+	for i := 0; i < 3; i++ {
+		srv.Fail(amadeustest.Search, amadeustest.Failure{Status: 503})
+	}
+	_, err := client.Search(searchParams(t))
+	var apiErr *amadeus.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 503 {
+		t.Fatalf("got error %v, want 503", err)
+	}
+	if n := srv.Requests(amadeustest.Search); n != 3 {
+		t.Errorf("got %d search requests, want 3", n)
+	}
+}
+
+func TestSearchGivesUpOnLongRetryAfter(t *testing.T) {
+	srv := amadeustest.NewServer()
+	defer srv.Close()
+	// The stand-in's client waits at most 10ms between attempts
+	client := newClient(t, srv)
+
+	srv.Fail(amadeustest.Search, amadeustest.Failure{Status: 429, RetryAfter: time.Minute})
+	start := time.Now()
+	_, err := client.Search(searchParams(t))
+	var apiErr *amadeus.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 429 {
+		t.Fatalf("got error %v, want 429", err)
+	}
+	// The caller can tell when to try again
+	if apiErr.RetryAfter != time.Minute {
+		t.Errorf("got RetryAfter %v, want 1m", apiErr.RetryAfter)
+	}
+	if n := srv.Requests(amadeustest.Search); n != 1 {
+		t.Errorf("got %d search requests, want 1", n)
+	}
+	if d := time.Since(start); d > 10*time.Second {
+		t.Errorf("search took %v, want it to give up right away", d)
+	}
+}
+
+func TestSearchWaitsForRetryAfterWithinDeadline(t *testing.T) {
+	srv := amadeustest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv)
+
+	// Longer than the client's backoffs, but within the deadline
+	srv.Fail(amadeustest.Search, amadeustest.Failure{Status: 429, RetryAfter: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.SearchContext(ctx, searchParams(t)); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.Requests(amadeustest.Search); n != 2 {
+		t.Errorf("got %d search requests, want 2", n)
+	}
+	if d := time.Since(start); d < time.Second {
+		t.Errorf("search took %v, want it to wait the second the API asked for", d)
+	}
+
+	// A deadline that is too close gives up right away
+	srv.Fail(amadeustest.Search, amadeustest.Failure{Status: 429, RetryAfter: time.Minute})
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	start = time.Now()
+	if _, err := client.SearchContext(ctx, searchParams(t)); err == nil {
+		t.Fatal("search succeeded, want 429")
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Errorf("search took %v, want it to give up right away", d)
+	}
+}
+
+func TestBookingIsNotRetriedAfterServerError(t *testing.T) {
+	srv := amadeustest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv)
+
+	offers, err := client.Search(searchParams(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Fail(amadeustest.Booking, amadeustest.Failure{Status: 503})
+	if _, err := client.Book(offers.Data[0].ID, bookingParams(t)); err == nil {
+		t.Fatal("booking succeeded, want 503")
+	}
+	if n := srv.Requests(amadeustest.Booking); n != 1 {
+		t.Errorf("got %d booking requests, want 1: the first one may have been processed", n)
+	}
+}
+
+func TestExpiredTokenIsRenewed(t *testing.T) {
+	srv := amadeustest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv)
+
+	if _, err := client.Search(searchParams(t)); err != nil {
+		t.Fatal(err)
+	}
+	srv.ExpireTokens()
+	if _, err := client.Search(searchParams(t)); err != nil {
+		t.Fatalf("search after ExpireTokens: %v", err)
+	}
+	if n := srv.Requests(amadeustest.Token); n != 2 {
+		t.Errorf("got %d token requests, want 2", n)
+	}
+	// The first search, the one rejected with 401, and its repetition
+	if n := srv.Requests(amadeustest.Search); n != 3 {
+		t.Errorf("got %d search requests, want 3", n)
+	}
+}
+
+func TestWrongCredentials(t *testing.T) {
+	srv := amadeustest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv, amadeus.WithCredentials("someone", "wrong"))
+
+	if _, err := client.Search(searchParams(t)); err == nil {
+		t.Fatal("search succeeded with wrong credentials")
+	}
+	if n := srv.Requests(amadeustest.Search); n != 0 {
+		t.Errorf("got %d search requests without a token, want 0", n)
+	}
+}
+
+func TestBookAndCancel(t *testing.T) {
+	srv := amadeustest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv)
+
+	offers, err := client.Search(searchParams(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	offer := offers.Data[0]
+	booking, err := client.Book(offer.ID, bookingParams(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if booking.Data.ID == "" || len(booking.Data.Transfers) != 1 {
+		t.Fatalf("got order %q with %d transfers, want an order with 1 transfer", booking.Data.ID, len(booking.Data.Transfers))
+	}
+	transfer := booking.Data.Transfers[0]
+	if transfer.OfferID != offer.ID {
+		t.Errorf("booked offer %q, want %q", transfer.OfferID, offer.ID)
+	}
+	if !transfer.Start.DateTime.Equal(offer.Start.DateTime) {
+		t.Errorf("transfer starts %v, want %v as offered", transfer.Start.DateTime, offer.Start.DateTime)
+	}
+
+	cancellation, err := client.Cancel(booking.Data.ID, transfer.ConfirmNbr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancellation.Data.ConfirmNbr != transfer.ConfirmNbr || cancellation.Data.ReservationStatus != "CANCELLED" {
+		t.Errorf("got cancellation %+v, want %s CANCELLED", cancellation.Data, transfer.ConfirmNbr)
+	}
+}
+
+func TestCancelUnknownOrder(t *testing.T) {
+	srv := amadeustest.NewServer()
+	defer srv.Close()
+	client := newClient(t, srv)
+
+	if _, err := client.Cancel("NO-SUCH-ORDER", "1"); err == nil {
+		t.Fatal("cancelled an order that does not exist")
+	}
+}
+
+func TestCloseStopsTokenManager(t *testing.T) {
+	srv := amadeustest.NewServer()
+	defer srv.Close()
+	client := srv.Client()
+
+	if _, err := client.Search(searchParams(t)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Close returns once the token manager has returned
+	closed := make(chan struct{})
+	go func() {
+		client.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not return")
+	}
+
+	if _, err := client.Search(searchParams(t)); !errors.Is(err, amadeus.ErrClientClosed) {
+		t.Errorf("search after Close: got %v, want ErrClientClosed", err)
+	}
+	if n := srv.Requests(amadeustest.Token); n != 1 {
+		t.Errorf("got %d token requests, want 1", n)
+	}
+	// Closing twice is fine
+	client.Close()
+}
@@ -0,0 +1,127 @@
+package amadeustest
+
+// sampleOffers is the default response of the search endpoint.
+// Start and end locations and times are filled in from each search
+// request (see fillOffer), so the offers fit any search.
+const sampleOffers = `{
+  "data": [
+    {
+      "type": "transfer-offer",
+      "id": "OFFER-PRIVATE-1",
+      "transferType": "PRIVATE",
+      "vehicle": {
+        "code": "SED",
+        "category": "BU",
+        "description": "Mercedes-Benz E-Class or similar",
+        "seats": [{"count": 3}],
+        "baggages": [{"count": 3, "size": "M"}],
+        "imageURL": "https://example.com/vehicles/sedan.png"
+      },
+      "serviceProvider": {
+        "code": "STD",
+        "name": "Stand-in Limousines",
+        "logoUrl": "https://example.com/providers/std.png",
+        "termsUrl": "https://example.com/providers/std/terms"
+      },
+      "quotation": {
+        "monetaryAmount": "89.00",
+        "currencyCode": "EUR",
+        "isEstimated": false,
+        "base": {"monetaryAmount": "74.17"},
+        "taxes": [{"monetaryAmount": "14.83"}],
+        "totalTaxes": {"monetaryAmount": "14.83"},
+        "totalFees": {"monetaryAmount": "0.00"}
+      },
+      "cancellationRules": [
+        {
+          "feeType": "PERCENTAGE",
+          "feeValue": "0",
+          "metricType": "HOURS",
+          "metricMin": "24",
+          "metricMax": "9999",
+          "ruleDescription": "Free cancellation up to 24 hours before pickup"
+        },
+        {
+          "feeType": "PERCENTAGE",
+          "feeValue": "100",
+          "metricType": "HOURS",
+          "metricMin": "0",
+          "metricMax": "24",
+          "ruleDescription": "No refund within 24 hours of pickup"
+        }
+      ],
+      "methodsOfPaymentAccepted": ["CREDIT_CARD"]
+    },
+    {
+      "type": "transfer-offer",
+      "id": "OFFER-SHARED-1",
+      "transferType": "SHARED",
+      "vehicle": {
+        "code": "VAN",
+        "category": "ST",
+        "description": "Shared shuttle van",
+        "seats": [{"count": 8}],
+        "baggages": [{"count": 8, "size": "M"}],
+        "imageURL": "https://example.com/vehicles/van.png"
+      },
+      "serviceProvider": {
+        "code": "SHU",
+        "name": "Stand-in Shuttles",
+        "logoUrl": "https://example.com/providers/shu.png",
+        "termsUrl": "https://example.com/providers/shu/terms"
+      },
+      "quotation": {
+        "monetaryAmount": "32.50",
+        "currencyCode": "EUR",
+        "isEstimated": false,
+        "base": {"monetaryAmount": "27.08"},
+        "taxes": [{"monetaryAmount": "5.42"}],
+        "totalTaxes": {"monetaryAmount": "5.42"},
+        "totalFees": {"monetaryAmount": "0.00"}
+      },
+      "cancellationRules": [
+        {
+          "feeType": "VALUE",
+          "feeValue": "5.00",
+          "currencyCode": "EUR",
+          "metricType": "HOURS",
+          "metricMin": "0",
+          "metricMax": "9999",
+          "ruleDescription": "Cancellation fee of 5 EUR"
+        }
+      ],
+      "methodsOfPaymentAccepted": ["CREDIT_CARD", "INVOICE"]
+    },
+    {
+      "type": "transfer-offer",
+      "id": "OFFER-TAXI-1",
+      "transferType": "TAXI",
+      "vehicle": {
+        "code": "CAR",
+        "category": "ST",
+        "description": "Taxi",
+        "seats": [{"count": 4}],
+        "baggages": [{"count": 2, "size": "M"}],
+        "imageURL": "https://example.com/vehicles/car.png"
+      },
+      "serviceProvider": {
+        "code": "TXI",
+        "name": "Stand-in Taxis",
+        "logoUrl": "https://example.com/providers/txi.png",
+        "termsUrl": "https://example.com/providers/txi/terms"
+      },
+      "quotation": {
+        "monetaryAmount": "61.20",
+        "currencyCode": "EUR",
+        "isEstimated": true,
+        "base": {"monetaryAmount": "56.00"},
+        "discount": {"monetaryAmount": "4.80"},
+        "taxes": [{"monetaryAmount": "10.00"}],
+        "totalTaxes": {"monetaryAmount": "10.00"},
+        "totalFees": {"monetaryAmount": "0.00"}
+      },
+      "cancellationRules": [],
+      "methodsOfPaymentAccepted": ["CREDIT_CARD"]
+    }
+  ]
+}`
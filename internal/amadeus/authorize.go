@@ -1,77 +1,165 @@
 package amadeus
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+	neturl "net/url"
 	"strings"
 	"time"
 )
 
-// token returns the current access token. If none exists yet, or if the existing one has expired, it fetches a new one from the Amadeus authorization API. If fetching fails, token returns an error.
-func (c *Client) token() (string, error) {
-	t := <-c.accessToken
-	return t.Token, t.Err
+// Backoff between failed token requests. The retry policy already
+// covers short outages within a single token request; this backoff
+// keeps a broken configuration (such as wrong credentials) from
+// hammering the authorization API.
+const (
+	minTokenBackoff = time.Second
+	maxTokenBackoff = time.Minute
+)
+
+// ErrClientClosed is returned by all API calls after Close was called.
+var ErrClientClosed = errors.New("amadeus: client closed")
+
+// token returns the current access token. If none exists yet, or if the existing one has expired, it fetches a new one from the Amadeus authorization API. If fetching fails, token returns an error. If ctx is done before a token is available, token returns the context's error.
+func (c *Client) token(ctx context.Context) (string, error) {
+	select {
+	case t := <-c.accessToken:
+		return t.Token, t.Err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-c.done:
+		return "", ErrClientClosed
+	}
+}
+
+// invalidateToken tells the token manager that the API rejected the given token, so that it fetches a new one before serving the next token. It returns when the new token is available to token(), or when ctx is done.
+func (c *Client) invalidateToken(ctx context.Context, token string) {
+	select {
+	case c.invalidate <- token:
+	case <-ctx.Done():
+	case <-c.done:
+	}
 }
 
-// startTokenFetcher starts a goroutine that fetches a new access token from the Amadeus authorization API if there is none yet, or if the current one expires. It returns channels for returning the current token, or an error if the token could not be fetched.
+// refreshToken is the token manager. It runs in its own goroutine, started by New, and fetches a new access token from the Amadeus authorization API if there is none yet, if the current one is about to expire, or if the API rejected it. Meanwhile, it serves the current token, or the error that occurred while fetching it, through the accessToken channel. It returns when Close is called.
 func (c *Client) refreshToken() {
+	defer close(c.stopped)
+
 	var token string
-	var expiration time.Duration
 	var err error
+	var failures int
+
+	// refresh fetches a new token and returns when to fetch the next one.
+	refresh := func() time.Duration {
+		var lifespan time.Duration
+		token, lifespan, err = c.authorize(c.closeCtx)
+		if err != nil || lifespan <= 0 {
+			// Back off exponentially rather than retrying right away.
+			failures++
+			backoff := minTokenBackoff << (failures - 1)
+			if backoff > maxTokenBackoff || backoff <= 0 {
+				backoff = maxTokenBackoff
+			}
+			return backoff
+		}
+		failures = 0
+		// We want a new token *before* the current one expires,
+		// so refresh when 90% of its lifespan has passed.
+		return lifespan * 90 / 100
+	}
 
 	// Set the initial token, before any client can request it.
-	token, expiration, err = authorize(c.baseURL)
-
-	// Set a new timer to fire when 90% of the expiration duration has passed.
-	// We want a new token *before* the current one expires.
-	expired := time.After(expiration * 90 / 100)
+	expired := time.NewTimer(refresh())
+	defer expired.Stop()
 
 	for {
 		select {
-		// The expiration timer has fired and wrote the current time to `expired`.
-		case <-expired:
-			token, expiration, err = authorize(c.baseURL)
-			// Set a new timer to fire when 90% of the expiration duration has passed.
-			expired = time.After(expiration * 90 / 100)
+		// The expiration timer has fired.
+		case <-expired.C:
+			expired.Reset(refresh())
+
+		case stale := <-c.invalidate:
+			// The API rejected a token with 401 Unauthorized. Fetch a
+			// new one, unless it has been replaced already since the
+			// rejected request was sent.
+			if stale == token {
+				if !expired.Stop() {
+					// Drain the channel if the timer fired meanwhile.
+					select {
+					case <-expired.C:
+					default:
+					}
+				}
+				expired.Reset(refresh())
+			}
 
 		case c.accessToken <- tokenResponse{Token: token, Err: err}:
 			// Someone has read the token, nothing to do.
 			// The next iteration will send the token to the channel again.
+
+		case <-c.done:
+			return
 		}
 	}
 }
 
-// authorize reads client ID and secret from the environment variables and updates the access token and its lifespan (in seconds) from the Amadeus authorization API.
-func authorize(baseURL string) (token string, lifespan time.Duration, err error) {
+// Close stops the token manager and cancels a token request in flight.
+// After Close, all API calls fail with ErrClientClosed.
+// It is safe to call Close more than once.
+func (c *Client) Close() error {
+	c.closeOnce.Do(func() {
+		c.cancelClose()
+		close(c.done)
+	})
+	<-c.stopped
+	return nil
+}
 
-	url := baseURL + "/security/oauth2/token"
-	method := "POST"
+// authorize fetches a new access token and its lifespan (in seconds) from the Amadeus authorization API. It asks the client's credentials provider for the client ID and secret each time, so that rotated credentials are picked up.
+func (c *Client) authorize(ctx context.Context) (token string, lifespan time.Duration, err error) {
 
-	id := os.Getenv("AMADEUS_CLIENT_ID")
-	secret := os.Getenv("AMADEUS_CLIENT_SECRET")
+	url := c.baseURL + "/security/oauth2/token"
+	method := "POST"
 
-	if id == "" || secret == "" {
-		return "", 0, fmt.Errorf("authorize: missing client ID or secret (check the environment variables AMADEUS_CLIENT_ID and AMADEUS_CLIENT_SECRET)")
+	creds, err := c.credentials.Credentials(ctx)
+	if err != nil {
+		return "", 0, fmt.Errorf("authorize: %w", err)
 	}
 
-	payload := strings.NewReader("client_id=" + id +
-		"&client_secret=" + secret +
-		"&grant_type=client_credentials")
+	payload := neturl.Values{
+		"client_id":     {creds.ClientID},
+		"client_secret": {creds.ClientSecret},
+		"grant_type":    {"client_credentials"},
+	}.Encode()
+
+	// Token requests do not change any state, so all transient
+	// failures can be retried.
+	err = c.retry(ctx, method, "/security/oauth2/token", retryTransient, func() error {
+		token, lifespan, err = c.requestToken(ctx, method, url, payload)
+		return err
+	})
+	return token, lifespan, err
+}
 
-	client := &http.Client{
-		Timeout: time.Second * 10,
-	}
-	req, err := http.NewRequest(method, url, payload)
+// requestToken makes a single attempt to fetch an access token.
+func (c *Client) requestToken(ctx context.Context, method, url, payload string) (token string, lifespan time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, strings.NewReader(payload))
 
 	if err != nil {
-		return "", 0, fmt.Errorf("authorize: http.NewRequest: %w", err)
+		return "", 0, fmt.Errorf("authorize: http.NewRequestWithContext: %w", err)
 	}
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	res, err := client.Do(req)
+	err = c.waitForRateLimit(ctx, "/security/oauth2/token")
+	if err != nil {
+		return "", 0, fmt.Errorf("authorize: c.waitForRateLimit: %w", err)
+	}
+
+	res, err := c.httpClient.Do(req)
 	if err != nil {
 		return "", 0, fmt.Errorf("authorize: client.Do: %w", err)
 	}
@@ -88,16 +176,31 @@ func authorize(baseURL string) (token string, lifespan time.Duration, err error)
 	// struct completely, which we use here to simplify the unmarshalling.
 	var authResponse AuthResponse
 	err = json.Unmarshal(body, &authResponse)
-	if err != nil {
+	if err != nil && res.StatusCode < 400 {
 		return "", 0, fmt.Errorf("authorize: json.Unmarshal: %w", err)
 	}
-	if authResponse.Error != "" {
-		return "", 0, fmt.Errorf("authorize: %w (%s: %s (error: %s, code: %d)",
-			err,
-			authResponse.Title,
-			authResponse.ErrorDescription,
-			authResponse.Error,
-			authResponse.Code)
+	if authResponse.Error != "" || res.StatusCode >= 400 {
+		apiErr := &APIError{
+			StatusCode: res.StatusCode,
+			Method:     req.Method,
+			Path:       req.URL.Path,
+			RetryAfter: parseRetryAfter(res.Header.Get("Retry-After")),
+		}
+		if authResponse.Error != "" {
+			// The authorization API uses the OAuth2 error format
+			// rather than the "errors" array of the other APIs.
+			title := authResponse.Title
+			if title == "" {
+				title = authResponse.Error
+			}
+			apiErr.Errors = []ErrorEntry{{
+				Status: res.StatusCode,
+				Code:   authResponse.Code,
+				Title:  title,
+				Detail: authResponse.ErrorDescription,
+			}}
+		}
+		return "", 0, fmt.Errorf("authorize: %w", apiErr)
 	}
 
 	return authResponse.AccessToken,
@@ -1,131 +1,65 @@
 package amadeus
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
-	"time"
+	"net/url"
 )
 
 // Book receives an offer ID that the user selects from the transfer
-// offers page, and returns a BookingResponse struct containing a booking
+// offers page, together with the passenger and payment details,
+// and returns a BookingResponse struct containing a booking
 // confirmation, or an error.
-func (c *Client) Book(offerId string) (BookingResponse, error) {
-
-	url := c.baseURL + "/ordering/transfer-orders?offerId=" + offerId
-	method := "POST"
-
-	// This data is typically collected beforehand, e.g. when the
-	// client registers with the service and when they book a flight.
-	// For brevity, we use mock data instead.
-	payload := strings.NewReader(`{
-  "data": {
-    "note": "Note to driver",
-    "passengers": [
-      {
-        "firstName": "John",
-        "lastName": "Doe",
-        "title": "MR",
-        "contacts": {
-          "phoneNumber": "+33123456789",
-          "email": "user@email.com"
-        },
-        "billingAddress": {
-          "line": "Avenue de la Bourdonnais, 19",
-          "zip": "75007",
-          "countryCode": "FR",
-          "cityName": "Paris"
-        }
-      }
-    ],
-    "payment": {
-      "methodOfPayment": "CREDIT_CARD",
-      "creditCard": {
-        "number": "4111111111111111",
-        "holderName": "JOHN DOE",
-        "vendorCode": "VI",
-        "expiryDate": "0928",
-        "cvv": "111"
-      }
-    },
-    "startConnectedSegment": {
-      "transportationType": "FLIGHT",
-      "transportationNumber": "AF380",
-      "departure": {
-        "uicCode": "7400001",
-        "iataCode": "CDG",
-        "localDateTime": "` + time.Now().Format("2006-01-02T15:04:05") + `"
-      },
-      "arrival": {
-        "uicCode": "7400001",
-        "iataCode": "CDG",
-        "localDateTime": "` + time.Now().Format("2006-01-02T15:04:05") + `"
-      }
-    },
-    "endConnectedSegment": {
-      "transportationType": "FLIGHT",
-      "transportationNumber": "AF380",
-      "departure": {
-        "uicCode": "7400001",
-        "iataCode": "CDG",
-        "localDateTime": "` + time.Now().Format("2006-01-02T15:04:05") + `"
-      },
-      "arrival": {
-        "uicCode": "7400001",
-        "iataCode": "CDG",
-        "localDateTime": "` + time.Now().Format("2006-01-02T15:04:05") + `"
-      }
-    }
-  }`)
-
-	client := &http.Client{
-		Timeout: time.Second * 10,
-	}
-	req, err := http.NewRequest(method, url, payload)
+func (c *Client) Book(offerID string, p BookingParameters) (BookingResponse, error) {
+	return c.BookContext(context.Background(), offerID, p)
+}
 
+// BookContext is like Book but honours the cancellation and
+// deadline of ctx, both while waiting for an access token and
+// during the API call.
+func (c *Client) BookContext(ctx context.Context, offerID string, p BookingParameters) (BookingResponse, error) {
+	params, err := json.Marshal(p)
 	if err != nil {
-		return BookingResponse{}, fmt.Errorf("book: http.NewRequest: %w", err)
+		return BookingResponse{}, fmt.Errorf("book: json.Marshal: %w", err)
 	}
 
-	token, err := c.token()
+	// API errors are returned as *APIError (see request.go).
+	// Booking is not idempotent: retrying a request that reached the API
+	// could book the transfer twice, so only unsent requests are retried.
+	result := BookingResponse{}
+	err = c.do(ctx, "POST", "/ordering/transfer-orders?offerId="+url.QueryEscape(offerID), params, retryUnsent, &result)
 	if err != nil {
-		return BookingResponse{}, fmt.Errorf("book: c.token: %w", err)
+		return BookingResponse{}, fmt.Errorf("book: %w", err)
 	}
 
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Authorization", "Bearer "+token)
-
-	res, err := client.Do(req)
-	if err != nil {
-		return BookingResponse{}, fmt.Errorf("book: client.Do: %w", err)
-	}
-	defer res.Body.Close()
+	// The API sends local times without a zone (see SearchContext).
+	result.localize()
+	return result, nil
+}
 
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return BookingResponse{}, fmt.Errorf("book: io.ReadAll: %w", err)
+// localize moves the date-times of all transfers into the zone of
+// the airport at their start or end.
+func (r *BookingResponse) localize() {
+	for i := range r.Data.Transfers {
+		r.Data.Transfers[i].Localize()
 	}
+}
 
-	// Check for API errors.
-	// HTTP status is 200 even if the booking fails,
-	// because technically, the call succeeded.
-	// Hence, we check for the occurrence of "errors" in the response body.
-	if bytes.Contains(body, []byte("errors")) {
-		errorResult := BookingErrorResponse{}
-		err = json.Unmarshal(body, &errorResult)
-		if err != nil {
-			return BookingResponse{}, fmt.Errorf("book: json.Unmarshal: %w", err)
-		}
-		return BookingResponse{}, fmt.Errorf("Booking failed: %s (code %d)", errorResult.Errors[0].Detail, errorResult.Errors[0].Code)
+// AirportCode returns the IATA code of the airport at the end of the
+// transfer, or at its start if it ends at an address.
+func (t Transfer) AirportCode() string {
+	if t.End.LocationCode != "" {
+		return t.End.LocationCode
 	}
+	return t.Start.LocationCode
+}
 
-	result := BookingResponse{}
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return BookingResponse{}, fmt.Errorf("book: json.Unmarshal: %w", err)
-	}
-	return result, nil
+// Localize moves the start and end date-times of the transfer into the
+// zone of its airport. Book does this for the transfers it returns; call
+// it for transfers that were stored as JSON, which does not keep the zone.
+func (t *Transfer) Localize() {
+	loc, _ := AirportLocation(t.AirportCode())
+	t.Start.DateTime = t.Start.DateTime.In(loc)
+	t.End.DateTime = t.End.DateTime.In(loc)
 }
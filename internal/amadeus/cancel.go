@@ -0,0 +1,32 @@
+package amadeus
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// Cancel receives the order ID and the confirmation number of a booked
+// transfer and calls the Transfer Management API to cancel the transfer.
+// It returns a CancellationResponse struct containing the new
+// reservation status, or an error if the cancellation fails.
+func (c *Client) Cancel(orderID, confirmNbr string) (CancellationResponse, error) {
+	return c.CancelContext(context.Background(), orderID, confirmNbr)
+}
+
+// CancelContext is like Cancel but honours the cancellation and
+// deadline of ctx, both while waiting for an access token and
+// during the API call.
+func (c *Client) CancelContext(ctx context.Context, orderID, confirmNbr string) (CancellationResponse, error) {
+	path := "/ordering/transfer-orders/" + url.PathEscape(orderID) +
+		"/transfers/cancellation?confirmNbr=" + url.QueryEscape(confirmNbr)
+
+	// API errors are returned as *APIError (see request.go).
+	// Like bookings, cancellations are only retried if they were not sent.
+	result := CancellationResponse{}
+	err := c.do(ctx, "POST", path, nil, retryUnsent, &result)
+	if err != nil {
+		return CancellationResponse{}, fmt.Errorf("cancel: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,236 @@
+// Package cassette records Amadeus API traffic to disk and replays it,
+// so that demos and CI runs can work with real responses without
+// network access or credentials.
+//
+// Both the Recorder and the Replayer are http.RoundTrippers that plug
+// into the client with amadeus.WithTransport:
+//
+//	rec := cassette.NewRecorder("testdata/cassettes", nil)
+//	client := amadeus.New(amadeus.WithTransport(rec))
+//
+//	rep, err := cassette.NewReplayer("testdata/cassettes")
+//	client := amadeus.New(amadeus.WithTransport(rep), amadeus.WithCredentials("replay", "replay"))
+//
+// Every request/response pair is stored as one JSON file. Access
+// tokens, client credentials, payment card details and the names,
+// contacts and billing addresses of passengers are redacted before
+// anything is written. Recordings of bookings still contain personal
+// data, such as pickup addresses, flight numbers and notes to the
+// driver, so review them before sharing or committing them.
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Redacted replaces sensitive values in recordings.
+const Redacted = "REDACTED"
+
+// Interaction is a recorded request/response pair, as stored on disk.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request.
+type Request struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	// Query is the query string with its parameters sorted by name.
+	Query string `json:"query,omitempty"`
+	// Body is the normalized, redacted request body.
+	Body string `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// JSON holds the body if it is valid JSON, Text otherwise.
+	JSON json.RawMessage `json:"json,omitempty"`
+	Text string          `json:"text,omitempty"`
+}
+
+// recordedHeaders are the response headers that are worth replaying.
+var recordedHeaders = []string{"Content-Type", "Retry-After"}
+
+// sensitiveKeys are redacted wherever they occur in JSON and form bodies.
+var sensitiveKeys = map[string]bool{
+	"access_token":  true,
+	"client_id":     true,
+	"client_secret": true,
+	"username":      true,
+	"cvv":           true,
+	"expiryDate":    true,
+	// Passengers
+	"firstName":      true,
+	"lastName":       true,
+	"email":          true,
+	"phoneNumber":    true,
+	"billingAddress": true,
+}
+
+// sensitiveCardKeys are additionally redacted inside "creditCard" objects.
+var sensitiveCardKeys = map[string]bool{
+	"number":     true,
+	"holderName": true,
+}
+
+// newRequest records the method, path, query and normalized body of r.
+func newRequest(r *http.Request, body []byte) Request {
+	return Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query().Encode(),
+		Body:   normalizeBody(r.Header.Get("Content-Type"), body),
+	}
+}
+
+// key identifies requests that are served the same recorded response.
+func (r Request) key() string {
+	return r.Method + " " + r.Path + "?" + r.Query + "\n" + r.Body
+}
+
+// fileName returns a readable, unique name for the n-th recording of the request.
+func (r Request) fileName(n int) string {
+	sum := sha256.Sum256([]byte(r.key()))
+	slug := strings.NewReplacer("/", "_", "?", "_", "&", "_", "=", "-").Replace(strings.Trim(r.Path, "/"))
+	return fmt.Sprintf("%s_%s_%s_%d.json", r.Method, slug, hex.EncodeToString(sum[:6]), n)
+}
+
+// newResponse records the status, the relevant headers and the redacted body of res.
+func newResponse(res *http.Response, body []byte) Response {
+	rec := Response{Status: res.StatusCode, Headers: map[string]string{}}
+	for _, h := range recordedHeaders {
+		if v := res.Header.Get(h); v != "" {
+			rec.Headers[h] = v
+		}
+	}
+	var v any
+	if json.Unmarshal(body, &v) == nil {
+		rec.JSON = marshalIndent(redactJSON(v, ""))
+	} else {
+		rec.Text = string(body)
+	}
+	return rec
+}
+
+// httpResponse turns a recorded response back into an *http.Response for req.
+func (r Response) httpResponse(req *http.Request) *http.Response {
+	body := r.Text
+	if len(r.JSON) > 0 {
+		body = string(r.JSON)
+	}
+	header := http.Header{}
+	for k, v := range r.Headers {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status)),
+		StatusCode:    r.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// normalizeBody returns a canonical, redacted form of a request body:
+// JSON is re-encoded with sorted keys and no insignificant whitespace,
+// form bodies are re-encoded with sorted parameters.
+func normalizeBody(contentType string, body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return ""
+	}
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err == nil {
+			for k := range form {
+				if sensitiveKeys[k] {
+					form[k] = []string{Redacted}
+				}
+			}
+			return form.Encode()
+		}
+	}
+	var v any
+	if json.Unmarshal(body, &v) == nil {
+		b, _ := json.Marshal(redactJSON(v, ""))
+		return string(b)
+	}
+	return string(body)
+}
+
+// redactJSON replaces the values of sensitive keys in a decoded JSON value.
+// parent is the key under which v is stored.
+func redactJSON(v any, parent string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if sensitiveKeys[k] || (parent == "creditCard" && sensitiveCardKeys[k]) {
+				v[k] = redactAll(child)
+				continue
+			}
+			v[k] = redactJSON(child, k)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = redactJSON(child, parent)
+		}
+	}
+	return v
+}
+
+// redactAll replaces all strings and numbers in a decoded JSON value.
+// Objects and arrays keep their shape, so that a redacted billing
+// address still decodes as an address.
+func redactAll(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			v[k] = redactAll(child)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = redactAll(child)
+		}
+		return v
+	case nil:
+		return nil
+	}
+	return Redacted
+}
+
+// readBody reads and closes the body of a request.
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	defer r.Body.Close()
+	return io.ReadAll(r.Body)
+}
+
+// marshalIndent encodes v as indented JSON without escaping HTML
+// characters, which keeps recorded URLs and form bodies readable.
+func marshalIndent(v any) []byte {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil
+	}
+	return bytes.TrimSpace(buf.Bytes())
+}
@@ -0,0 +1,229 @@
+package cassette
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newHTTPRequest returns a request with a body of the given content type
+func newHTTPRequest(t *testing.T, method, target, contentType, body string) (*http.Request, []byte) {
+	t.Helper()
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	return r, []byte(body)
+}
+
+func TestRequestKey(t *testing.T) {
+	const (
+		form = "application/x-www-form-urlencoded"
+		json = "application/json"
+	)
+	tests := []struct {
+		name string
+		a, b [4]string // method, target, content type, body
+		same bool
+	}{
+		{
+			name: "query parameters in another order",
+			a:    [4]string{"GET", "/v1/x?b=2&a=1", "", ""},
+			b:    [4]string{"GET", "/v1/x?a=1&b=2", "", ""},
+			same: true,
+		},
+		{
+			name: "different query values",
+			a:    [4]string{"GET", "/v1/x?a=1", "", ""},
+			b:    [4]string{"GET", "/v1/x?a=2", "", ""},
+		},
+		{
+			name: "different methods",
+			a:    [4]string{"GET", "/v1/x", "", ""},
+			b:    [4]string{"POST", "/v1/x", "", ""},
+		},
+		{
+			name: "different paths",
+			a:    [4]string{"GET", "/v1/x", "", ""},
+			b:    [4]string{"GET", "/v1/y", "", ""},
+		},
+		{
+			name: "JSON with other key order and whitespace",
+			a:    [4]string{"POST", "/v1/x", json, `{"b": 2, "a": {"d": 4, "c": 3}}`},
+			b:    [4]string{"POST", "/v1/x", json, "{\"a\":{\"c\":3,\"d\":4},\n\"b\":2}"},
+			same: true,
+		},
+		{
+			name: "different JSON",
+			a:    [4]string{"POST", "/v1/x", json, `{"a": 1}`},
+			b:    [4]string{"POST", "/v1/x", json, `{"a": 2}`},
+		},
+		{
+			name: "form parameters in another order",
+			a:    [4]string{"POST", "/v1/token", form, "grant_type=client_credentials&client_id=a&client_secret=b"},
+			b:    [4]string{"POST", "/v1/token", form, "client_secret=b&grant_type=client_credentials&client_id=a"},
+			same: true,
+		},
+		{
+			name: "other credentials",
+			a:    [4]string{"POST", "/v1/token", form, "grant_type=client_credentials&client_id=a&client_secret=b"},
+			b:    [4]string{"POST", "/v1/token", form, "grant_type=client_credentials&client_id=c&client_secret=d"},
+			same: true,
+		},
+		{
+			name: "other card and passenger",
+			a:    [4]string{"POST", "/v1/x", json, `{"passengers": [{"firstName": "Jean", "email": "j@example.com"}], "payment": {"creditCard": {"number": "4111111111111111", "cvv": "123", "vendorCode": "VI"}}}`},
+			b:    [4]string{"POST", "/v1/x", json, `{"passengers": [{"firstName": "Anna", "email": "a@example.com"}], "payment": {"creditCard": {"number": "5555555555554444", "cvv": "456", "vendorCode": "VI"}}}`},
+			same: true,
+		},
+		{
+			name: "other card vendor",
+			a:    [4]string{"POST", "/v1/x", json, `{"creditCard": {"number": "1", "vendorCode": "VI"}}`},
+			b:    [4]string{"POST", "/v1/x", json, `{"creditCard": {"number": "1", "vendorCode": "CA"}}`},
+		},
+		{
+			name: "empty and whitespace body",
+			a:    [4]string{"POST", "/v1/x", json, ""},
+			b:    [4]string{"POST", "/v1/x", json, " \n"},
+			same: true,
+		},
+		{
+			name: "text that is not JSON",
+			a:    [4]string{"POST", "/v1/x", "text/plain", "hello"},
+			b:    [4]string{"POST", "/v1/x", "text/plain", "hello!"},
+		},
+	}
+	for _, tt := range tests {
+		ra, ba := newHTTPRequest(t, tt.a[0], tt.a[1], tt.a[2], tt.a[3])
+		rb, bb := newHTTPRequest(t, tt.b[0], tt.b[1], tt.b[2], tt.b[3])
+		ka, kb := newRequest(ra, ba).key(), newRequest(rb, bb).key()
+		if (ka == kb) != tt.same {
+			t.Errorf("%s: keys\n%s\n%s\nsame = %v, want %v", tt.name, ka, kb, ka == kb, tt.same)
+		}
+	}
+}
+
+func TestNormalizeBodyRedacts(t *testing.T) {
+	tests := []struct {
+		name, contentType, body string
+		want                    string
+	}{
+		{
+			name:        "token request",
+			contentType: "application/x-www-form-urlencoded",
+			body:        "grant_type=client_credentials&client_id=id&client_secret=secret",
+			want:        "client_id=REDACTED&client_secret=REDACTED&grant_type=client_credentials",
+		},
+		{
+			name:        "credit card",
+			contentType: "application/json",
+			body:        `{"creditCard": {"number": "4111111111111111", "holderName": "JEAN DUPONT", "vendorCode": "VI", "expiryDate": "1230", "cvv": "123"}}`,
+			want:        `{"creditCard":{"cvv":"REDACTED","expiryDate":"REDACTED","holderName":"REDACTED","number":"REDACTED","vendorCode":"VI"}}`,
+		},
+		{
+			name:        "number outside a card",
+			contentType: "application/json",
+			body:        `{"number": "42"}`,
+			want:        `{"number":"42"}`,
+		},
+		{
+			name:        "passenger",
+			contentType: "application/json",
+			body: `{"passengers": [{"title": "MR", "firstName": "Jean", "lastName": "Dupont",
+				"contacts": {"email": "jean@example.com", "phoneNumber": "+33123456789"},
+				"billingAddress": {"line": "Rue de Rivoli 10", "zip": "75001", "cityName": "Paris", "countryCode": "FR"}}]}`,
+			want: `{"passengers":[{"billingAddress":{"cityName":"REDACTED","countryCode":"REDACTED","line":"REDACTED","zip":"REDACTED"},` +
+				`"contacts":{"email":"REDACTED","phoneNumber":"REDACTED"},"firstName":"REDACTED","lastName":"REDACTED","title":"MR"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		if got := normalizeBody(tt.contentType, []byte(tt.body)); got != tt.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"access_token": "secret-token", "path": "`+r.URL.Path+`"}`)
+	}))
+	defer api.Close()
+
+	dir := t.TempDir()
+	recorder := &http.Client{Transport: NewRecorder(dir, nil)}
+	res, err := recorder.Post(api.URL+"/v1/token", "application/x-www-form-urlencoded",
+		strings.NewReader("client_id=id&client_secret=secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if !strings.Contains(string(body), "secret-token") {
+		t.Errorf("recorder changed the live response: %s", body)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("got recordings %v, %v, want one", files, err)
+	}
+	recording, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"secret-token", "client_secret=secret", "client_id=id"} {
+		if strings.Contains(string(recording), secret) {
+			t.Errorf("recording contains %q:\n%s", secret, recording)
+		}
+	}
+
+	replayer, err := NewReplayer(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := &http.Client{Transport: replayer}
+	// Other credentials, in another order, get the same response
+	res, err = replay.Post("http://replay.invalid/v1/token", "application/x-www-form-urlencoded",
+		strings.NewReader("client_secret=other&client_id=other"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || !strings.Contains(string(body), `"path": "/v1/token"`) {
+		t.Errorf("replayed %d %s", res.StatusCode, body)
+	}
+
+	_, err = replay.Get("http://replay.invalid/v1/other")
+	if !errors.Is(err, ErrNoRecording) {
+		t.Errorf("unrecorded request: got %v, want ErrNoRecording", err)
+	}
+}
+
+func TestRecorderKeepsResponseWhenSaveFails(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data": []}`)
+	}))
+	defer api.Close()
+
+	// The directory cannot be created, since a file has its name
+	dir := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(dir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	recorder := &http.Client{Transport: NewRecorder(dir, nil)}
+	res, err := recorder.Get(api.URL + "/v1/x")
+	if err != nil {
+		t.Fatalf("got %v, want the live response", err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != `{"data": []}` {
+		t.Errorf("got body %s", body)
+	}
+}
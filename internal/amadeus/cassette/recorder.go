@@ -0,0 +1,86 @@
+package cassette
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Recorder is an http.RoundTripper that passes requests on to another
+// RoundTripper and writes each request/response pair, redacted, to a
+// directory.
+type Recorder struct {
+	dir  string
+	next http.RoundTripper
+
+	mu    sync.Mutex
+	count map[string]int // request key -> number of recordings
+}
+
+// NewRecorder returns a Recorder that writes to dir and sends the
+// requests through next, or through http.DefaultTransport if next is nil.
+// Existing recordings of the same requests are overwritten.
+func NewRecorder(dir string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{dir: dir, next: next, count: map[string]int{}}
+}
+
+// RoundTrip sends the request and records the exchange. The request
+// was made, so failing to write the recording does not fail it: the
+// error is logged, and the gap shows when the cassette is replayed.
+func (rec *Recorder) RoundTrip(r *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(r)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: reading request body: %w", err)
+	}
+
+	// A RoundTripper must not modify the request, so send a copy
+	// with a fresh body.
+	out := r.Clone(r.Context())
+	out.Body = io.NopCloser(bytes.NewReader(reqBody))
+	res, err := rec.next.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("cassette: reading response body: %w", err)
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	interaction := Interaction{
+		Request:  newRequest(r, reqBody),
+		Response: newResponse(res, resBody),
+	}
+	if err := rec.save(interaction); err != nil {
+		log.Printf("cassette: recording %s %s: %v", r.Method, r.URL.Path, err)
+	}
+	return res, nil
+}
+
+// save writes an interaction to its own file.
+func (rec *Recorder) save(interaction Interaction) error {
+	rec.mu.Lock()
+	key := interaction.Request.key()
+	rec.count[key]++
+	n := rec.count[key]
+	rec.mu.Unlock()
+
+	if err := os.MkdirAll(rec.dir, 0o755); err != nil {
+		return err
+	}
+	b := marshalIndent(interaction)
+	if b == nil {
+		return fmt.Errorf("encoding %s %s", interaction.Request.Method, interaction.Request.Path)
+	}
+	return os.WriteFile(filepath.Join(rec.dir, interaction.Request.fileName(n)), b, 0o644)
+}
@@ -0,0 +1,88 @@
+package cassette
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrNoRecording is returned (wrapped) by Replayer.RoundTrip for
+// requests that were not recorded.
+var ErrNoRecording = errors.New("cassette: no recording for request")
+
+// Replayer is an http.RoundTripper that serves recorded responses
+// without any network access.
+type Replayer struct {
+	mu       sync.Mutex
+	recorded map[string][]Interaction // request key -> recordings in order
+	served   map[string]int           // request key -> number of responses served
+}
+
+// NewReplayer loads all recordings from dir.
+func NewReplayer(dir string) (*Replayer, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("cassette: %w", err)
+	}
+	// The file names end in the recording number. Sorting by it
+	// puts repeated recordings of a request in order.
+	sort.Slice(files, func(i, j int) bool {
+		return recordingNumber(files[i]) < recordingNumber(files[j])
+	})
+
+	rep := &Replayer{recorded: map[string][]Interaction{}, served: map[string]int{}}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("cassette: %w", err)
+		}
+		var interaction Interaction
+		if err := json.Unmarshal(b, &interaction); err != nil {
+			return nil, fmt.Errorf("cassette: %s: %w", file, err)
+		}
+		key := interaction.Request.key()
+		rep.recorded[key] = append(rep.recorded[key], interaction)
+	}
+	return rep, nil
+}
+
+// RoundTrip serves the recorded response for a request with the same
+// method, path, query and normalized body. Repeated requests are
+// served the recordings in the order they were recorded; after the
+// last one, it is served again.
+func (rep *Replayer) RoundTrip(r *http.Request) (*http.Response, error) {
+	body, err := readBody(r)
+	if err != nil {
+		return nil, fmt.Errorf("cassette: reading request body: %w", err)
+	}
+	req := newRequest(r, body)
+	key := req.key()
+
+	rep.mu.Lock()
+	defer rep.mu.Unlock()
+
+	recordings := rep.recorded[key]
+	if len(recordings) == 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoRecording, req.Method, req.Path)
+	}
+	i := rep.served[key]
+	if i >= len(recordings) {
+		i = len(recordings) - 1
+	}
+	rep.served[key]++
+	return recordings[i].Response.httpResponse(r), nil
+}
+
+// recordingNumber extracts n from a file name created by Request.fileName.
+func recordingNumber(file string) int {
+	name := strings.TrimSuffix(filepath.Base(file), ".json")
+	var n int
+	fmt.Sscan(name[strings.LastIndex(name, "_")+1:], &n)
+	return n
+}
@@ -0,0 +1,103 @@
+package amadeus
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Credentials are the API key (client ID) and API secret (client secret)
+// of an Amadeus for Developers application.
+type Credentials struct {
+	ClientID     string
+	ClientSecret string
+}
+
+// CredentialsProvider supplies the credentials for fetching access tokens.
+// The client asks for the credentials each time it fetches a token,
+// so a provider can pick up rotated secrets without a restart.
+type CredentialsProvider interface {
+	Credentials(ctx context.Context) (Credentials, error)
+}
+
+// WithCredentialsProvider sets the source of the client ID and secret.
+// Without this option, the client uses EnvCredentials{}.
+func WithCredentialsProvider(p CredentialsProvider) Option {
+	return func(c *Client) {
+		c.credentials = p
+	}
+}
+
+// StaticCredentials provides a fixed client ID and secret.
+type StaticCredentials Credentials
+
+// Credentials returns the static credentials.
+func (s StaticCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	if s.ClientID == "" || s.ClientSecret == "" {
+		return Credentials{}, fmt.Errorf("static credentials: missing client ID or secret")
+	}
+	return Credentials(s), nil
+}
+
+// EnvCredentials reads the client ID and secret from environment variables.
+// The zero value reads AMADEUS_CLIENT_ID and AMADEUS_CLIENT_SECRET.
+type EnvCredentials struct {
+	IDVar     string
+	SecretVar string
+}
+
+// Credentials reads the environment variables.
+func (e EnvCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	idVar, secretVar := e.IDVar, e.SecretVar
+	if idVar == "" {
+		idVar = "AMADEUS_CLIENT_ID"
+	}
+	if secretVar == "" {
+		secretVar = "AMADEUS_CLIENT_SECRET"
+	}
+
+	creds := Credentials{
+		ClientID:     os.Getenv(idVar),
+		ClientSecret: os.Getenv(secretVar),
+	}
+	if creds.ClientID == "" || creds.ClientSecret == "" {
+		return Credentials{}, fmt.Errorf("missing client ID or secret (check the environment variables %s and %s)", idVar, secretVar)
+	}
+	return creds, nil
+}
+
+// FileCredentials reads the client ID and secret from two files, such as
+// Docker or Kubernetes secret mounts. The files are read each time the
+// client fetches a token, so rotated secrets take effect with the next
+// token refresh. Leading and trailing whitespace is ignored.
+type FileCredentials struct {
+	IDFile     string
+	SecretFile string
+}
+
+// Credentials reads the files.
+func (f FileCredentials) Credentials(ctx context.Context) (Credentials, error) {
+	id, err := readSecretFile(f.IDFile)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("client ID: %w", err)
+	}
+	secret, err := readSecretFile(f.SecretFile)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("client secret: %w", err)
+	}
+	return Credentials{ClientID: id, ClientSecret: secret}, nil
+}
+
+// readSecretFile returns the trimmed content of a file, which must not be empty.
+func readSecretFile(name string) (string, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimSpace(string(b))
+	if s == "" {
+		return "", fmt.Errorf("%s is empty", name)
+	}
+	return s, nil
+}
@@ -0,0 +1,124 @@
+package amadeus
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStaticCredentials(t *testing.T) {
+	tests := []struct {
+		creds   StaticCredentials
+		wantErr bool
+	}{
+		{StaticCredentials{ClientID: "id", ClientSecret: "secret"}, false},
+		{StaticCredentials{ClientID: "id"}, true},
+		{StaticCredentials{ClientSecret: "secret"}, true},
+		{StaticCredentials{}, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.creds.Credentials(context.Background())
+		if (err != nil) != tt.wantErr || (err == nil && got != Credentials(tt.creds)) {
+			t.Errorf("%+v: got %+v, %v, want error %v", tt.creds, got, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEnvCredentials(t *testing.T) {
+	t.Setenv("AMADEUS_CLIENT_ID", "default-id")
+	t.Setenv("AMADEUS_CLIENT_SECRET", "default-secret")
+	t.Setenv("TEST_CLIENT_ID", "custom-id")
+	t.Setenv("TEST_CLIENT_SECRET", "custom-secret")
+	t.Setenv("TEST_EMPTY", "")
+
+	tests := []struct {
+		name    string
+		env     EnvCredentials
+		want    Credentials
+		wantErr bool
+	}{
+		{"default variables", EnvCredentials{}, Credentials{"default-id", "default-secret"}, false},
+		{"custom variables", EnvCredentials{IDVar: "TEST_CLIENT_ID", SecretVar: "TEST_CLIENT_SECRET"}, Credentials{"custom-id", "custom-secret"}, false},
+		{"one custom variable", EnvCredentials{SecretVar: "TEST_CLIENT_SECRET"}, Credentials{"default-id", "custom-secret"}, false},
+		{"missing variable", EnvCredentials{IDVar: "TEST_NOT_SET"}, Credentials{}, true},
+		{"empty variable", EnvCredentials{SecretVar: "TEST_EMPTY"}, Credentials{}, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.env.Credentials(context.Background())
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: got %+v, %v, want %+v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	id := write("id", "file-id\n")
+	secret := write("secret", "  file-secret \n")
+	empty := write("empty", " \n")
+
+	tests := []struct {
+		name    string
+		files   FileCredentials
+		want    Credentials
+		wantErr bool
+	}{
+		{"trimmed", FileCredentials{IDFile: id, SecretFile: secret}, Credentials{"file-id", "file-secret"}, false},
+		{"missing file", FileCredentials{IDFile: id, SecretFile: filepath.Join(dir, "missing")}, Credentials{}, true},
+		{"empty file", FileCredentials{IDFile: empty, SecretFile: secret}, Credentials{}, true},
+		{"directory", FileCredentials{IDFile: dir, SecretFile: secret}, Credentials{}, true},
+		{"no file names", FileCredentials{}, Credentials{}, true},
+	}
+	for _, tt := range tests {
+		got, err := tt.files.Credentials(context.Background())
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s: got %+v, %v, want %+v, error %v", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	// A rotated secret is read on the next call
+	files := FileCredentials{IDFile: id, SecretFile: secret}
+	write("secret", "rotated-secret\n")
+	if got, err := files.Credentials(context.Background()); err != nil || got.ClientSecret != "rotated-secret" {
+		t.Errorf("after rotation: got %+v, %v, want rotated-secret", got, err)
+	}
+}
+
+func TestAuthorizeEncodesCredentials(t *testing.T) {
+	var form url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		form, _ = url.ParseQuery(string(body))
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"access_token": "token", "expires_in": 1799}`)
+	}))
+	defer ts.Close()
+
+	creds := StaticCredentials{ClientID: "id+1", ClientSecret: "a&b=c+d%20 e"}
+	c := New(WithCredentialsProvider(creds), WithBaseURL(ts.URL))
+	defer c.Close()
+	if _, _, err := c.authorize(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := form.Get("client_id"); got != creds.ClientID {
+		t.Errorf("client_id: got %q, want %q", got, creds.ClientID)
+	}
+	if got := form.Get("client_secret"); got != creds.ClientSecret {
+		t.Errorf("client_secret: got %q, want %q", got, creds.ClientSecret)
+	}
+	if got := form.Get("grant_type"); got != "client_credentials" || len(form) != 3 {
+		t.Errorf("got form %v, want only client_id, client_secret and grant_type", form)
+	}
+}
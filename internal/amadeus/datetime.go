@@ -0,0 +1,189 @@
+package amadeus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LocalDateTimeLayout is the format of local date-times in the
+// Amadeus APIs. It has no time zone: the time zone is implied by the
+// location, typically an airport.
+const LocalDateTimeLayout = "2006-01-02T15:04:05"
+
+// localDateTimeLayoutShort is the format of the value of an HTML
+// datetime-local input, which has no seconds.
+const localDateTimeLayoutShort = "2006-01-02T15:04"
+
+// LocalDateTime is a wall-clock date and time in a time zone.
+//
+// The Amadeus APIs send and expect local date-times without a zone
+// (see LocalDateTimeLayout). When unmarshaled, a LocalDateTime is in
+// UTC until the zone is known; the Client methods then move the
+// date-times of their responses into the zone of the airport with In.
+// The zero value means "not set".
+type LocalDateTime struct {
+	t time.Time
+}
+
+// NewLocalDateTime returns the wall-clock time of t in t's location.
+// Seconds are kept, smaller units are dropped.
+func NewLocalDateTime(t time.Time) LocalDateTime {
+	return LocalDateTime{t: t.Truncate(time.Second)}
+}
+
+// ErrSkippedTime is returned by ParseLocalDateTime for a wall-clock
+// time that does not exist in the time zone, because the clocks go
+// forward over it.
+var ErrSkippedTime = errors.New("amadeus: time skipped by a daylight saving change")
+
+// ParseLocalDateTime parses a date-time in LocalDateTimeLayout, or
+// without seconds as sent by an HTML datetime-local input, as a
+// wall-clock time in loc. A nil loc means UTC.
+func ParseLocalDateTime(s string, loc *time.Location) (LocalDateTime, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+	layout := LocalDateTimeLayout
+	t, err := time.ParseInLocation(layout, s, loc)
+	if err != nil {
+		layout = localDateTimeLayoutShort
+		t, err = time.ParseInLocation(layout, s, loc)
+		if err != nil {
+			return LocalDateTime{}, fmt.Errorf("invalid date-time %q, expected format YYYY-MM-DDTHH:MM:SS", s)
+		}
+	}
+	// A time in a gap, such as 02:30 on the night the clocks go forward
+	// in Paris, is moved after the gap by ParseInLocation. Its wall clock
+	// then differs from the same time parsed in UTC, which has no gaps.
+	if wall, _ := time.Parse(layout, s); t.Hour() != wall.Hour() || t.Minute() != wall.Minute() || t.Day() != wall.Day() {
+		return LocalDateTime{}, fmt.Errorf("%w: %s in %s", ErrSkippedTime, s, loc)
+	}
+	return LocalDateTime{t: t}, nil
+}
+
+// Time returns the date-time as an instant in its location.
+func (d LocalDateTime) Time() time.Time { return d.t }
+
+// Location returns the time zone of the date-time.
+func (d LocalDateTime) Location() *time.Location { return d.t.Location() }
+
+// IsZero reports whether the date-time is not set.
+func (d LocalDateTime) IsZero() bool { return d.t.IsZero() }
+
+// String formats the date-time in LocalDateTimeLayout, or returns ""
+// if it is not set.
+func (d LocalDateTime) String() string {
+	if d.IsZero() {
+		return ""
+	}
+	return d.t.Format(LocalDateTimeLayout)
+}
+
+// In returns the same wall-clock date and time in loc. Unlike
+// time.Time.In, it does not convert the instant: 10:00 in UTC becomes
+// 10:00 in loc. Use it to attach a zone to a date-time that was parsed
+// without one. Like time.Date, it moves a wall-clock time that loc
+// skips, such as 02:30 on the night the clocks go forward, after the
+// gap; parse input with ParseLocalDateTime to reject such times.
+func (d LocalDateTime) In(loc *time.Location) LocalDateTime {
+	if d.IsZero() || loc == nil {
+		return d
+	}
+	y, mo, day := d.t.Date()
+	h, mi, sec := d.t.Clock()
+	return LocalDateTime{t: time.Date(y, mo, day, h, mi, sec, 0, loc)}
+}
+
+// Sub returns the elapsed time between e and d. Both are instants,
+// so the result is correct across DST changes and time zones.
+func (d LocalDateTime) Sub(e LocalDateTime) time.Duration { return d.t.Sub(e.t) }
+
+// Until returns the time from now until d.
+func (d LocalDateTime) Until() time.Duration { return time.Until(d.t) }
+
+// Before reports whether d is before e.
+func (d LocalDateTime) Before(e LocalDateTime) bool { return d.t.Before(e.t) }
+
+// After reports whether d is after e.
+func (d LocalDateTime) After(e LocalDateTime) bool { return d.t.After(e.t) }
+
+// Equal reports whether d and e are the same instant.
+func (d LocalDateTime) Equal(e LocalDateTime) bool { return d.t.Equal(e.t) }
+
+// MarshalJSON encodes the date-time as a string in LocalDateTimeLayout,
+// or as null if it is not set.
+func (d LocalDateTime) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON decodes a string in LocalDateTimeLayout as a wall-clock
+// time in UTC. An empty string or null leaves the date-time unset.
+func (d *LocalDateTime) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		*d = LocalDateTime{}
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		*d = LocalDateTime{}
+		return nil
+	}
+	v, err := ParseLocalDateTime(s, time.UTC)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// airportTimeZones maps the IATA codes of common airports to their
+// IANA time zones.
+var airportTimeZones = map[string]string{
+	// France
+	"CDG": "Europe/Paris", "ORY": "Europe/Paris", "BVA": "Europe/Paris",
+	"NCE": "Europe/Paris", "LYS": "Europe/Paris", "MRS": "Europe/Paris",
+	"TLS": "Europe/Paris", "BOD": "Europe/Paris", "NTE": "Europe/Paris",
+	// Rest of Europe
+	"LHR": "Europe/London", "LGW": "Europe/London", "STN": "Europe/London", "LTN": "Europe/London",
+	"DUB": "Europe/Dublin", "AMS": "Europe/Amsterdam", "BRU": "Europe/Brussels",
+	"FRA": "Europe/Berlin", "MUC": "Europe/Berlin", "BER": "Europe/Berlin",
+	"ZRH": "Europe/Zurich", "GVA": "Europe/Zurich", "VIE": "Europe/Vienna",
+	"MAD": "Europe/Madrid", "BCN": "Europe/Madrid", "LIS": "Europe/Lisbon",
+	"FCO": "Europe/Rome", "MXP": "Europe/Rome", "ATH": "Europe/Athens",
+	"CPH": "Europe/Copenhagen", "ARN": "Europe/Stockholm", "OSL": "Europe/Oslo",
+	"HEL": "Europe/Helsinki", "IST": "Europe/Istanbul",
+	// Americas
+	"JFK": "America/New_York", "EWR": "America/New_York", "LGA": "America/New_York",
+	"BOS": "America/New_York", "ORD": "America/Chicago", "LAX": "America/Los_Angeles",
+	"SFO": "America/Los_Angeles", "YYZ": "America/Toronto", "GRU": "America/Sao_Paulo",
+	// Asia, Middle East and Oceania
+	"DXB": "Asia/Dubai", "DOH": "Asia/Qatar", "SIN": "Asia/Singapore",
+	"HKG": "Asia/Hong_Kong", "NRT": "Asia/Tokyo", "HND": "Asia/Tokyo",
+	"ICN": "Asia/Seoul", "BKK": "Asia/Bangkok", "SYD": "Australia/Sydney",
+}
+
+// AirportLocation returns the time zone of an airport, given its IATA
+// code. For unknown airports, or if the time zone database is not
+// available, it returns UTC and false. UTC is only a placeholder:
+// callers that compare a date-time at the airport with the current time
+// must check ok, since the wall clock may be hours away from UTC.
+func AirportLocation(iataCode string) (*time.Location, bool) {
+	name, ok := airportTimeZones[iataCode]
+	if !ok {
+		return time.UTC, false
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC, false
+	}
+	return loc, true
+}
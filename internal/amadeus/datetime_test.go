@@ -0,0 +1,171 @@
+package amadeus
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata" // as in main, so that the tests do not depend on the system
+)
+
+func TestParseLocalDateTime(t *testing.T) {
+	paris, ok := AirportLocation("CDG")
+	if !ok {
+		t.Fatal("no time zone for CDG")
+	}
+	tests := []struct {
+		in      string
+		loc     *time.Location
+		want    string // in RFC 3339, with the offset of the zone
+		wantErr error
+	}{
+		{"2026-06-01T10:30:00", paris, "2026-06-01T10:30:00+02:00", nil},
+		{"2026-06-01T10:30", paris, "2026-06-01T10:30:00+02:00", nil},
+		{"2026-01-15T10:30", paris, "2026-01-15T10:30:00+01:00", nil},
+		{"2026-06-01T10:30", nil, "2026-06-01T10:30:00Z", nil},
+		// The clocks go forward from 02:00 to 03:00
+		{"2026-03-29T01:59", paris, "2026-03-29T01:59:00+01:00", nil},
+		{"2026-03-29T02:30", paris, "", ErrSkippedTime},
+		{"2026-03-29T03:00", paris, "2026-03-29T03:00:00+02:00", nil},
+		// The clocks go back: 02:30 happens twice and is accepted
+		{"2026-10-25T02:30", paris, "2026-10-25T02:30:00+", nil},
+		{"2026-03-29T02:30", time.UTC, "2026-03-29T02:30:00Z", nil},
+		{"", paris, "", nil},
+		{"2026-06-01", paris, "", nil},
+		{"2026-06-01 10:30", paris, "", nil},
+		{"2026-13-01T10:30", paris, "", nil},
+	}
+	for _, tt := range tests {
+		d, err := ParseLocalDateTime(tt.in, tt.loc)
+		if tt.want == "" {
+			if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("ParseLocalDateTime(%q, %v) = %v, %v, want error %v", tt.in, tt.loc, d, err, tt.wantErr)
+			}
+			continue
+		}
+		got := d.Time().Format(time.RFC3339)
+		if err != nil || got[:len(tt.want)] != tt.want {
+			t.Errorf("ParseLocalDateTime(%q, %v) = %s, %v, want %s", tt.in, tt.loc, got, err, tt.want)
+		}
+	}
+}
+
+func TestLocalDateTimeIn(t *testing.T) {
+	paris, _ := AirportLocation("CDG")
+	newYork, _ := AirportLocation("JFK")
+	tests := []struct {
+		in   string
+		loc  *time.Location
+		want string
+	}{
+		{"2026-06-01T10:30:00", paris, "2026-06-01T10:30:00+02:00"},
+		{"2026-06-01T10:30:00", newYork, "2026-06-01T10:30:00-04:00"},
+		{"2026-06-01T10:30:00", nil, "2026-06-01T10:30:00Z"},
+		// A time that Paris skips is moved after the gap, like time.Date does
+		{"2026-03-29T02:30:00", paris, "2026-03-29T03:30:00+02:00"},
+	}
+	for _, tt := range tests {
+		d, err := ParseLocalDateTime(tt.in, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := d.In(tt.loc).Time().Format(time.RFC3339); got != tt.want {
+			t.Errorf("%s.In(%v) = %s, want %s", tt.in, tt.loc, got, tt.want)
+		}
+	}
+	if got := (LocalDateTime{}).In(paris); !got.IsZero() {
+		t.Errorf("zero value in Paris = %v, want zero", got)
+	}
+}
+
+func TestLocalDateTimeUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{`"2026-06-01T10:30:00"`, "2026-06-01T10:30:00", false},
+		{`"2026-06-01T10:30"`, "2026-06-01T10:30:00", false},
+		{`null`, "", false},
+		{`""`, "", false},
+		{`"tomorrow"`, "", true},
+		{`42`, "", true},
+	}
+	for _, tt := range tests {
+		// A set date-time is cleared by null and ""
+		d := NewLocalDateTime(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+		err := json.Unmarshal([]byte(tt.in), &d)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("unmarshal %s = %v, want error", tt.in, d)
+			}
+			continue
+		}
+		if err != nil || d.String() != tt.want || d.IsZero() != (tt.want == "") {
+			t.Errorf("unmarshal %s = %q, %v, want %q", tt.in, d, err, tt.want)
+		}
+		if !d.IsZero() && d.Location() != time.UTC {
+			t.Errorf("unmarshal %s: location %v, want UTC", tt.in, d.Location())
+		}
+	}
+}
+
+func TestLocalDateTimeCompareAcrossZones(t *testing.T) {
+	paris, _ := AirportLocation("CDG")
+	newYork, _ := AirportLocation("JFK")
+	at := func(s string, loc *time.Location) LocalDateTime {
+		d, err := ParseLocalDateTime(s, loc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	tests := []struct {
+		name          string
+		a, b          LocalDateTime
+		before, equal bool
+		sub           time.Duration
+	}{
+		{"same instant", at("2026-06-01T16:00", paris), at("2026-06-01T10:00", newYork), false, true, 0},
+		{"earlier wall clock, later instant", at("2026-06-01T09:00", newYork), at("2026-06-01T14:00", paris), false, false, time.Hour},
+		{"later wall clock, earlier instant", at("2026-06-01T14:00", paris), at("2026-06-01T09:00", newYork), true, false, -time.Hour},
+		// Across the change to summer time in Paris, an hour shorter
+		{"across DST", at("2026-03-29T01:00", paris), at("2026-03-29T04:00", paris), true, false, -2 * time.Hour},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Before(tt.b); got != tt.before {
+			t.Errorf("%s: Before = %v, want %v", tt.name, got, tt.before)
+		}
+		if got := tt.a.After(tt.b); got != (!tt.before && !tt.equal) {
+			t.Errorf("%s: After = %v, want %v", tt.name, got, !tt.before && !tt.equal)
+		}
+		if got := tt.a.Equal(tt.b); got != tt.equal {
+			t.Errorf("%s: Equal = %v, want %v", tt.name, got, tt.equal)
+		}
+		if got := tt.a.Sub(tt.b); got != tt.sub {
+			t.Errorf("%s: Sub = %v, want %v", tt.name, got, tt.sub)
+		}
+	}
+}
+
+func TestAirportLocation(t *testing.T) {
+	tests := []struct {
+		code   string
+		want   string
+		wantOK bool
+	}{
+		{"CDG", "Europe/Paris", true},
+		{"JFK", "America/New_York", true},
+		{"SYD", "Australia/Sydney", true},
+		// Unknown airports fall back to UTC, which callers must not trust
+		{"MIA", "UTC", false},
+		{"cdg", "UTC", false},
+		{"", "UTC", false},
+	}
+	for _, tt := range tests {
+		loc, ok := AirportLocation(tt.code)
+		if loc.String() != tt.want || ok != tt.wantOK {
+			t.Errorf("AirportLocation(%q) = %v, %v, want %s, %v", tt.code, loc, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package amadeus
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// The string types in this file hold the values that Amadeus defines
+// for some fields. Amadeus may add values at any time, so unmarshaling
+// never fails on an unknown value; Known reports whether a value is one
+// of the constants.
+
+// TransferType is the kind of service of a transfer.
+type TransferType string
+
+const (
+	TransferPrivate        TransferType = "PRIVATE"
+	TransferShared         TransferType = "SHARED"
+	TransferTaxi           TransferType = "TAXI"
+	TransferHourly         TransferType = "HOURLY"
+	TransferAirportExpress TransferType = "AIRPORT_EXPRESS"
+	TransferAirportBus     TransferType = "AIRPORT_BUS"
+)
+
+// Known reports whether t is one of the TransferType constants.
+func (t TransferType) Known() bool {
+	switch t {
+	case TransferPrivate, TransferShared, TransferTaxi, TransferHourly, TransferAirportExpress, TransferAirportBus:
+		return true
+	}
+	return false
+}
+
+func (t *TransferType) UnmarshalJSON(b []byte) error {
+	return unmarshalEnum(b, (*string)(t))
+}
+
+// PassengerType is the age group of a passenger.
+type PassengerType string
+
+const (
+	// PassengerAdult is a passenger aged 12 or over.
+	PassengerAdult PassengerType = "ADT"
+	// PassengerChild is a passenger under 12.
+	PassengerChild PassengerType = "CHD"
+)
+
+// childAgeLimit is the age from which a passenger counts as an adult.
+const childAgeLimit = 12
+
+// Known reports whether t is one of the PassengerType constants.
+func (t PassengerType) Known() bool {
+	return t == PassengerAdult || t == PassengerChild
+}
+
+func (t *PassengerType) UnmarshalJSON(b []byte) error {
+	return unmarshalEnum(b, (*string)(t))
+}
+
+// VehicleCategory is the comfort class of a vehicle.
+type VehicleCategory string
+
+const (
+	VehicleStandard   VehicleCategory = "ST"
+	VehicleBusiness   VehicleCategory = "BU"
+	VehicleFirstClass VehicleCategory = "FC"
+)
+
+// Known reports whether c is one of the VehicleCategory constants.
+func (c VehicleCategory) Known() bool {
+	return c == VehicleStandard || c == VehicleBusiness || c == VehicleFirstClass
+}
+
+func (c *VehicleCategory) UnmarshalJSON(b []byte) error {
+	return unmarshalEnum(b, (*string)(c))
+}
+
+// VehicleCode is the type of a vehicle.
+type VehicleCode string
+
+const (
+	VehicleMotorbike  VehicleCode = "MBR"
+	VehicleCar        VehicleCode = "CAR"
+	VehicleSedan      VehicleCode = "SED"
+	VehicleWagon      VehicleCode = "WGN"
+	VehicleElectric   VehicleCode = "ELC"
+	VehicleVan        VehicleCode = "VAN"
+	VehicleSUV        VehicleCode = "SUV"
+	VehicleLimousine  VehicleCode = "LMS"
+	VehicleTrain      VehicleCode = "TRN"
+	VehicleBus        VehicleCode = "BUS"
+	VehicleHelicopter VehicleCode = "HEL"
+)
+
+// Known reports whether c is one of the VehicleCode constants.
+func (c VehicleCode) Known() bool {
+	switch c {
+	case VehicleMotorbike, VehicleCar, VehicleSedan, VehicleWagon, VehicleElectric,
+		VehicleVan, VehicleSUV, VehicleLimousine, VehicleTrain, VehicleBus, VehicleHelicopter:
+		return true
+	}
+	return false
+}
+
+func (c *VehicleCode) UnmarshalJSON(b []byte) error {
+	return unmarshalEnum(b, (*string)(c))
+}
+
+// PaymentMethod is the way a transfer is paid.
+type PaymentMethod string
+
+const (
+	PaymentCreditCard             PaymentMethod = "CREDIT_CARD"
+	PaymentInvoice                PaymentMethod = "INVOICE"
+	PaymentTravelAccount          PaymentMethod = "TRAVEL_ACCOUNT"
+	PaymentPaymentServiceProvider PaymentMethod = "PAYMENT_SERVICE_PROVIDER"
+)
+
+// Known reports whether m is one of the PaymentMethod constants.
+func (m PaymentMethod) Known() bool {
+	switch m {
+	case PaymentCreditCard, PaymentInvoice, PaymentTravelAccount, PaymentPaymentServiceProvider:
+		return true
+	}
+	return false
+}
+
+func (m *PaymentMethod) UnmarshalJSON(b []byte) error {
+	return unmarshalEnum(b, (*string)(m))
+}
+
+// FeeType tells how the fee of a cancellation rule is calculated.
+type FeeType string
+
+const (
+	// FeePercentage is a percentage of the transfer's price.
+	FeePercentage FeeType = "PERCENTAGE"
+	// FeeValue is a fixed amount.
+	FeeValue FeeType = "VALUE"
+)
+
+// Known reports whether t is one of the FeeType constants.
+func (t FeeType) Known() bool {
+	return t == FeePercentage || t == FeeValue
+}
+
+func (t *FeeType) UnmarshalJSON(b []byte) error {
+	return unmarshalEnum(b, (*string)(t))
+}
+
+// MetricType is the unit of the time window of a cancellation rule.
+type MetricType string
+
+const (
+	MetricMinutes MetricType = "MINUTES"
+	MetricHours   MetricType = "HOURS"
+	MetricDays    MetricType = "DAYS"
+)
+
+// Known reports whether t is one of the MetricType constants.
+func (t MetricType) Known() bool {
+	return t == MetricMinutes || t == MetricHours || t == MetricDays
+}
+
+func (t *MetricType) UnmarshalJSON(b []byte) error {
+	return unmarshalEnum(b, (*string)(t))
+}
+
+// TransportationType is the kind of a connected segment.
+type TransportationType string
+
+const (
+	TransportationFlight TransportationType = "FLIGHT"
+	TransportationTrain  TransportationType = "TRAIN"
+)
+
+// Known reports whether t is one of the TransportationType constants.
+func (t TransportationType) Known() bool {
+	return t == TransportationFlight || t == TransportationTrain
+}
+
+func (t *TransportationType) UnmarshalJSON(b []byte) error {
+	return unmarshalEnum(b, (*string)(t))
+}
+
+// unmarshalEnum unmarshals an enum value into s. Strings are kept
+// exactly as received, so that an unknown value is shown and logged as
+// Amadeus sent it; null leaves s unchanged, and any other JSON value is
+// kept as its raw text rather than failing the whole response.
+func unmarshalEnum(b []byte, s *string) error {
+	b = bytes.TrimSpace(b)
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+	var v string
+	if err := json.Unmarshal(b, &v); err != nil {
+		*s = string(b)
+		return nil
+	}
+	*s = v
+	return nil
+}
@@ -0,0 +1,39 @@
+package amadeus
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUnmarshalEnum(t *testing.T) {
+	tests := []struct {
+		in        string
+		want      TransferType
+		wantKnown bool
+	}{
+		{`"PRIVATE"`, TransferPrivate, true},
+		{`"AIRPORT_EXPRESS"`, TransferAirportExpress, true},
+		// Unknown values are kept exactly as received
+		{`"HELICOPTER"`, "HELICOPTER", false},
+		{`"private"`, "private", false},
+		{`" PRIVATE "`, " PRIVATE ", false},
+		{`""`, "", false},
+		// Other JSON values are kept as their raw text
+		{`42`, "42", false},
+		{`{"code": "PRIVATE"}`, `{"code": "PRIVATE"}`, false},
+		// null leaves the value unchanged
+		{`null`, TransferShared, true},
+	}
+	for _, tt := range tests {
+		v := struct {
+			TransferType TransferType `json:"transferType"`
+		}{TransferType: TransferShared}
+		if err := json.Unmarshal([]byte(`{"transferType": `+tt.in+`}`), &v); err != nil {
+			t.Errorf("%s: %v", tt.in, err)
+			continue
+		}
+		if v.TransferType != tt.want || v.TransferType.Known() != tt.wantKnown {
+			t.Errorf("%s: got %q (known %v), want %q (known %v)", tt.in, v.TransferType, v.TransferType.Known(), tt.want, tt.wantKnown)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package amadeus
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APIError is returned by the Client methods when an Amadeus API
+// responds with an error status or an error payload.
+// Use errors.As to inspect it:
+//
+//	var apiErr *amadeus.APIError
+//	if errors.As(err, &apiErr) && apiErr.HasCode(477) { ... }
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Method and Path identify the request that failed.
+	Method string
+	Path   string
+	// Errors contains all error entries of the response.
+	// It can be empty if the response body was not an Amadeus
+	// error payload (for example, an error page of a proxy).
+	Errors []ErrorEntry
+	// RetryAfter is the wait time requested by the API through
+	// the Retry-After header, or 0 if there was none.
+	RetryAfter time.Duration
+}
+
+// ErrorEntry is a single entry of the "errors" array of an
+// Amadeus error response.
+type ErrorEntry struct {
+	Status int         `json:"status"`
+	Code   int         `json:"code"`
+	Title  string      `json:"title"`
+	Detail string      `json:"detail"`
+	Source ErrorSource `json:"source"`
+}
+
+// ErrorSource points at the part of the request that caused an error.
+type ErrorSource struct {
+	Parameter string `json:"parameter,omitempty"`
+	Pointer   string `json:"pointer,omitempty"`
+	Example   string `json:"example,omitempty"`
+}
+
+// errorResponse is the body of an Amadeus error response.
+type errorResponse struct {
+	Errors []ErrorEntry `json:"errors"`
+}
+
+// Error lists all error entries in a single line.
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "amadeus: %s %s: %d %s", e.Method, e.Path, e.StatusCode, http.StatusText(e.StatusCode))
+	for i, entry := range e.Errors {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+		b.WriteString(entry.String())
+	}
+	return b.String()
+}
+
+// HasCode reports whether any error entry has the given Amadeus error code.
+func (e *APIError) HasCode(code int) bool {
+	for _, entry := range e.Errors {
+		if entry.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// ParameterErrors returns the error entries that refer to a request
+// parameter, keyed by the parameter name.
+func (e *APIError) ParameterErrors() map[string][]ErrorEntry {
+	params := map[string][]ErrorEntry{}
+	for _, entry := range e.Errors {
+		if entry.Source.Parameter != "" {
+			params[entry.Source.Parameter] = append(params[entry.Source.Parameter], entry)
+		}
+	}
+	return params
+}
+
+// String formats the entry as "title: detail (code n, parameter p)".
+func (e ErrorEntry) String() string {
+	s := e.Title
+	if e.Detail != "" {
+		if s != "" {
+			s += ": "
+		}
+		s += e.Detail
+	}
+	s += fmt.Sprintf(" (code %d", e.Code)
+	if e.Source.Parameter != "" {
+		s += ", parameter " + e.Source.Parameter
+	}
+	return s + ")"
+}
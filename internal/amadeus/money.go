@@ -0,0 +1,356 @@
+package amadeus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimal is an exact decimal number, such as a monetary amount or a
+// percentage. It stores the digits as an integer coefficient and the
+// number of digits after the decimal point, so "12.50" is 1250 with
+// scale 2. Numbers of up to 18 digits, counting the digits before the
+// decimal point and all decimal places, are supported. Arithmetic whose
+// result does not fit returns ErrDecimalOverflow.
+// The zero value is 0.
+type Decimal struct {
+	coef  int64
+	scale int32
+}
+
+// maxDecimalDigits is the number of digits that always fit into an int64.
+const maxDecimalDigits = 18
+
+// ErrDecimalOverflow is returned by Decimal operations whose result has
+// too many digits.
+var ErrDecimalOverflow = errors.New("amadeus: decimal overflow")
+
+// ParseDecimal parses a decimal number such as "89", "-4.80" or "0.125".
+// It rejects numbers with more than 18 digits before the decimal point
+// and after it together, such as "0.00000000000000000001".
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	intDigits := strings.TrimLeft(strings.TrimLeft(intPart, "+-"), "0")
+	digits := strings.TrimLeft(intPart, "+-") + fracPart
+	if digits == "" || len(intDigits)+len(fracPart) > maxDecimalDigits || strings.ContainsAny(fracPart, "+-") {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	coef, err := strconv.ParseInt(strings.TrimRight(intPart, "0123456789")+digits, 10, 64)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	return Decimal{coef: coef, scale: int32(len(fracPart))}, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics on invalid input.
+// It is meant for constants.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// NewDecimal returns coef * 10^-scale, for example NewDecimal(1250, 2) for 12.50.
+// The scale is the number of decimal places, so it panics if scale is
+// negative: write NewDecimal(100, 0) rather than NewDecimal(1, -2).
+func NewDecimal(coef int64, scale int32) Decimal {
+	if scale < 0 {
+		panic(fmt.Sprintf("amadeus: NewDecimal with negative scale %d", scale))
+	}
+	return Decimal{coef: coef, scale: scale}
+}
+
+// String formats the number with all its decimal places, e.g. "12.50".
+func (d Decimal) String() string {
+	s := strconv.FormatInt(abs(d.coef), 10)
+	if d.scale > 0 {
+		if pad := int(d.scale) - len(s) + 1; pad > 0 {
+			s = strings.Repeat("0", pad) + s
+		}
+		s = s[:len(s)-int(d.scale)] + "." + s[len(s)-int(d.scale):]
+	}
+	if d.coef < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int32 { return d.scale }
+
+// Sign returns -1, 0 or +1.
+func (d Decimal) Sign() int {
+	switch {
+	case d.coef < 0:
+		return -1
+	case d.coef > 0:
+		return 1
+	}
+	return 0
+}
+
+// IsZero reports whether d is 0.
+func (d Decimal) IsZero() bool { return d.coef == 0 }
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal { return Decimal{coef: -d.coef, scale: d.scale} }
+
+// Add returns d + e, or ErrDecimalOverflow if the sum does not fit.
+func (d Decimal) Add(e Decimal) (Decimal, error) {
+	a, b, scale := align(d, e)
+	return fromBig(a.Add(a, b), scale)
+}
+
+// Sub returns d - e, or ErrDecimalOverflow if the difference does not fit.
+func (d Decimal) Sub(e Decimal) (Decimal, error) {
+	return d.Add(e.Neg())
+}
+
+// Mul returns d * e, or ErrDecimalOverflow if the product does not fit.
+// The scale of the result is the sum of both scales.
+func (d Decimal) Mul(e Decimal) (Decimal, error) {
+	return fromBig(new(big.Int).Mul(d.big(), e.big()), d.scale+e.scale)
+}
+
+// Cmp returns -1 if d < e, 0 if d == e, and +1 if d > e.
+func (d Decimal) Cmp(e Decimal) int {
+	a, b, _ := align(d, e)
+	return a.Cmp(b)
+}
+
+// Round rounds d to the given number of decimal places, rounding
+// halves away from zero. If d has fewer places, they are padded
+// with zeros as far as they fit, so that Round(2) of 12.5 is 12.50.
+// Negative places are treated as 0.
+func (d Decimal) Round(places int32) Decimal {
+	places = max(places, 0)
+	if places >= d.scale {
+		r, err := fromBig(new(big.Int).Mul(d.big(), pow10(places-d.scale)), places)
+		if err != nil {
+			return d
+		}
+		return r
+	}
+	r, _ := fromBig(roundBig(d.big(), d.scale-places), places)
+	return r
+}
+
+// Float64 returns the nearest float64. Use it for display purposes
+// only, such as scaling a bar chart, never for calculations.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// MarshalJSON encodes d as a JSON string, like the Amadeus APIs do.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON decodes a JSON string or number. An empty string or
+// null is decoded as 0.
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	if string(b) == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	s := string(b)
+	if len(b) > 0 && b[0] == '"' {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+	}
+	if strings.TrimSpace(s) == "" {
+		*d = Decimal{}
+		return nil
+	}
+	v, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// big returns the coefficient of d as a new big.Int, for arithmetic
+// that must not overflow.
+func (d Decimal) big() *big.Int { return big.NewInt(d.coef) }
+
+// fromBig returns coef * 10^-scale, or ErrDecimalOverflow if coef does
+// not fit into an int64.
+func fromBig(coef *big.Int, scale int32) (Decimal, error) {
+	if !coef.IsInt64() {
+		return Decimal{}, ErrDecimalOverflow
+	}
+	return Decimal{coef: coef.Int64(), scale: scale}, nil
+}
+
+// align returns the coefficients of d and e at the larger of both scales.
+func align(d, e Decimal) (*big.Int, *big.Int, int32) {
+	a, b := d.big(), e.big()
+	switch {
+	case d.scale < e.scale:
+		a.Mul(a, pow10(e.scale-d.scale))
+		return a, b, e.scale
+	case d.scale > e.scale:
+		b.Mul(b, pow10(d.scale-e.scale))
+	}
+	return a, b, d.scale
+}
+
+// roundBig divides coef by 10^n, rounding halves away from zero.
+func roundBig(coef *big.Int, n int32) *big.Int {
+	div := pow10(n)
+	q, r := new(big.Int).QuoRem(coef, div, new(big.Int))
+	if r.Abs(r).Lsh(r, 1).Cmp(div) >= 0 {
+		q.Add(q, big.NewInt(int64(coef.Sign())))
+	}
+	return q
+}
+
+// pow10 returns 10^n. The scales of Decimals are never negative, so
+// neither is n; big.Int.Exp would return 1 for a negative n.
+func pow10(n int32) *big.Int {
+	if n < 0 {
+		panic(fmt.Sprintf("amadeus: pow10 of negative exponent %d", n))
+	}
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// ErrCurrencyMismatch is returned by Money operations on amounts in
+// different currencies.
+var ErrCurrencyMismatch = errors.New("amadeus: currency mismatch")
+
+// Money is an exact amount in an ISO 4217 currency.
+//
+// In the Amadeus models, the amount and the currency code are separate
+// fields. Money fields unmarshal from the amount string, and the
+// enclosing type (for example, Quotation) fills in the currency.
+// Money fields marshal to the amount string only.
+type Money struct {
+	Amount   Decimal
+	Currency string
+}
+
+// ParseMoney parses an amount such as "89.00" in the given currency.
+func ParseMoney(amount, currency string) (Money, error) {
+	d, err := ParseDecimal(amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: d, Currency: currency}, nil
+}
+
+// String formats m as amount and currency code, e.g. "89.00 EUR".
+func (m Money) String() string {
+	if m.Currency == "" {
+		return m.Amount.String()
+	}
+	return m.Amount.String() + " " + m.Currency
+}
+
+// IsZero reports whether the amount is 0.
+func (m Money) IsZero() bool { return m.Amount.IsZero() }
+
+// Sign returns -1, 0 or +1 depending on the sign of the amount.
+func (m Money) Sign() int { return m.Amount.Sign() }
+
+// Neg returns -m.
+func (m Money) Neg() Money { return Money{Amount: m.Amount.Neg(), Currency: m.Currency} }
+
+// Add returns m + n. Both must be in the same currency, unless one of
+// them is zero without a currency, which is the neutral element.
+func (m Money) Add(n Money) (Money, error) {
+	currency, err := commonCurrency(m, n)
+	if err != nil {
+		return Money{}, err
+	}
+	amount, err := m.Amount.Add(n.Amount)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// Sub returns m - n, with the same currency rules as Add.
+func (m Money) Sub(n Money) (Money, error) {
+	return m.Add(n.Neg())
+}
+
+// Cmp compares the amounts of m and n, which must be in the same currency.
+// It returns -1 if m < n, 0 if m == n, and +1 if m > n.
+func (m Money) Cmp(n Money) (int, error) {
+	if _, err := commonCurrency(m, n); err != nil {
+		return 0, err
+	}
+	return m.Amount.Cmp(n.Amount), nil
+}
+
+// Percent returns p percent of m, rounded to the minor unit of the currency,
+// or ErrDecimalOverflow if the result does not fit.
+func (m Money) Percent(p Decimal) (Money, error) {
+	// Divide by 100 and round in one go, so that only the result has to fit
+	places := minorUnits(m.Currency)
+	coef := new(big.Int).Mul(m.Amount.big(), p.big())
+	if scale := m.Amount.scale + p.scale + 2; scale > places {
+		coef = roundBig(coef, scale-places)
+	} else {
+		coef.Mul(coef, pow10(places-scale))
+	}
+	amount, err := fromBig(coef, places)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: amount, Currency: m.Currency}, nil
+}
+
+// Sum adds up amounts in the same currency. The sum of no amounts is zero.
+func Sum(amounts ...Money) (Money, error) {
+	var total Money
+	for _, m := range amounts {
+		var err error
+		if total, err = total.Add(m); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
+
+// MarshalJSON encodes the amount as a JSON string.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return m.Amount.MarshalJSON()
+}
+
+// UnmarshalJSON decodes the amount from a JSON string or number.
+// The currency is left unchanged.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	return m.Amount.UnmarshalJSON(b)
+}
+
+// commonCurrency returns the currency of an operation on m and n.
+func commonCurrency(m, n Money) (string, error) {
+	switch {
+	case m.Currency == n.Currency:
+		return m.Currency, nil
+	case m.Currency == "" && m.IsZero():
+		return n.Currency, nil
+	case n.Currency == "" && n.IsZero():
+		return m.Currency, nil
+	}
+	return "", fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, n.Currency)
+}
@@ -0,0 +1,240 @@
+package amadeus
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		scale   int32
+		wantErr bool
+	}{
+		{in: "89", want: "89", scale: 0},
+		{in: "89.00", want: "89.00", scale: 2},
+		{in: "-4.80", want: "-4.80", scale: 2},
+		{in: "+4.8", want: "4.8", scale: 1},
+		{in: "0.125", want: "0.125", scale: 3},
+		{in: ".5", want: "0.5", scale: 1},
+		{in: "5.", want: "5", scale: 0},
+		{in: " 12.50 ", want: "12.50", scale: 2},
+		{in: "007.10", want: "7.10", scale: 2},
+		{in: "123456789012345678", want: "123456789012345678", scale: 0},
+		{in: "1234567890123456.78", want: "1234567890123456.78", scale: 2},
+		{in: "0.000000000000000001", want: "0.000000000000000001", scale: 18},
+		{in: "", wantErr: true},
+		{in: ".", wantErr: true},
+		{in: "-", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "1,50", wantErr: true},
+		{in: "1.2.3", wantErr: true},
+		{in: "1.-5", wantErr: true},
+		{in: "1e3", wantErr: true},
+		// More than 18 digits
+		{in: "1234567890123456789", wantErr: true},
+		{in: "12345678901234567.89", wantErr: true},
+		{in: "0.00000000000000000001", wantErr: true},
+		{in: "99999999999999999999", wantErr: true},
+	}
+	for _, tt := range tests {
+		d, err := ParseDecimal(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseDecimal(%q) = %v, want error", tt.in, d)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseDecimal(%q): %v", tt.in, err)
+			continue
+		}
+		if d.String() != tt.want || d.Scale() != tt.scale {
+			t.Errorf("ParseDecimal(%q) = %s with scale %d, want %s with scale %d", tt.in, d, d.Scale(), tt.want, tt.scale)
+		}
+	}
+}
+
+func TestNewDecimal(t *testing.T) {
+	tests := []struct {
+		coef  int64
+		scale int32
+		want  string
+	}{
+		{1250, 2, "12.50"},
+		{-5, 3, "-0.005"},
+		{100, 0, "100"},
+		{0, 2, "0.00"},
+	}
+	for _, tt := range tests {
+		if got := NewDecimal(tt.coef, tt.scale).String(); got != tt.want {
+			t.Errorf("NewDecimal(%d, %d) = %s, want %s", tt.coef, tt.scale, got, tt.want)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("NewDecimal(1, -2) did not panic")
+		}
+	}()
+	NewDecimal(1, -2)
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	max := MustParseDecimal("999999999999999999")
+	tests := []struct {
+		name    string
+		op      func() (Decimal, error)
+		want    string
+		wantErr error
+	}{
+		{"add", func() (Decimal, error) { return MustParseDecimal("1.5").Add(MustParseDecimal("2.25")) }, "3.75", nil},
+		{"sub", func() (Decimal, error) { return MustParseDecimal("1.5").Sub(MustParseDecimal("2.25")) }, "-0.75", nil},
+		{"mul", func() (Decimal, error) { return MustParseDecimal("1.5").Mul(MustParseDecimal("-2.25")) }, "-3.375", nil},
+		{"add large", func() (Decimal, error) { return max.Add(max) }, "1999999999999999998", nil},
+		{"add overflow", func() (Decimal, error) { return NewDecimal(math.MaxInt64, 0).Add(NewDecimal(1, 0)) }, "", ErrDecimalOverflow},
+		{"add rescale overflow", func() (Decimal, error) { return max.Add(MustParseDecimal("0.01")) }, "", ErrDecimalOverflow},
+		{"mul overflow", func() (Decimal, error) {
+			d := MustParseDecimal("123456789012345678")
+			return d.Mul(d)
+		}, "", ErrDecimalOverflow},
+	}
+	for _, tt := range tests {
+		got, err := tt.op()
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("%s: got %v, %v, want %v", tt.name, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got.String() != tt.want {
+			t.Errorf("%s: got %v, %v, want %s", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestDecimalCmp(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1", "1.00", 0},
+		{"1.5", "1.25", 1},
+		{"-1.5", "1.25", -1},
+		{"0.000000000000000001", "0", 1},
+		// Would overflow if both were brought to the same scale in an int64
+		{"999999999999999999", "0.000000000000000001", 1},
+		{"-999999999999999999", "0.000000000000000001", -1},
+	}
+	for _, tt := range tests {
+		if got := MustParseDecimal(tt.a).Cmp(MustParseDecimal(tt.b)); got != tt.want {
+			t.Errorf("%s.Cmp(%s) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		in     string
+		places int32
+		want   string
+	}{
+		{"12.5", 2, "12.50"},
+		{"12.345", 2, "12.35"},
+		{"12.344", 2, "12.34"},
+		{"-12.345", 2, "-12.35"},
+		{"0.5", 0, "1"},
+		{"-0.5", 0, "-1"},
+		{"0.000000000000000001", 2, "0.00"},
+		{"999999999999999999", 0, "999999999999999999"},
+		// Padding does not fit, so the value is kept as it is
+		{"999999999999999999", 2, "999999999999999999"},
+		{"12.5", -1, "13"},
+	}
+	for _, tt := range tests {
+		if got := MustParseDecimal(tt.in).Round(tt.places).String(); got != tt.want {
+			t.Errorf("%s.Round(%d) = %s, want %s", tt.in, tt.places, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyCmp(t *testing.T) {
+	eur := func(s string) Money { return Money{Amount: MustParseDecimal(s), Currency: "EUR"} }
+	if c, err := eur("10").Cmp(eur("9.99")); err != nil || c != 1 {
+		t.Errorf("10 EUR vs 9.99 EUR: got %d, %v, want 1", c, err)
+	}
+	if c, err := eur("0").Cmp(Money{}); err != nil || c != 0 {
+		t.Errorf("0 EUR vs zero value: got %d, %v, want 0", c, err)
+	}
+	if _, err := eur("10").Cmp(Money{Amount: MustParseDecimal("10"), Currency: "USD"}); !errors.Is(err, ErrCurrencyMismatch) {
+		t.Errorf("10 EUR vs 10 USD: got %v, want ErrCurrencyMismatch", err)
+	}
+}
+
+func TestMoneyPercent(t *testing.T) {
+	tests := []struct {
+		amount, currency, percent string
+		want                      string
+	}{
+		{"89.99", "EUR", "12.5", "11.25 EUR"},
+		{"89.00", "EUR", "100", "89.00 EUR"},
+		{"1000", "JPY", "33.3", "333 JPY"},
+		{"10.000", "KWD", "0.5", "0.050 KWD"},
+		// The product has more than 18 digits, the result does not
+		{"123456789.12", "EUR", "0.000000001", "0.00 EUR"},
+	}
+	for _, tt := range tests {
+		m, err := ParseMoney(tt.amount, tt.currency)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := m.Percent(MustParseDecimal(tt.percent))
+		if err != nil || got.String() != tt.want {
+			t.Errorf("%s%% of %s: got %v, %v, want %s", tt.percent, m, got, err, tt.want)
+		}
+	}
+
+	if _, err := mustParseMoney("999999999999999999", "EUR").Percent(MustParseDecimal("200")); !errors.Is(err, ErrDecimalOverflow) {
+		t.Errorf("200%% of a huge amount: got %v, want ErrDecimalOverflow", err)
+	}
+}
+
+func TestMoneyFormat(t *testing.T) {
+	tests := []struct {
+		amount, currency, locale string
+		want                     string
+	}{
+		{"1234.5", "EUR", "en", "€1,234.50"},
+		{"1234.5", "EUR", "en-US", "€1,234.50"},
+		{"1234.5", "EUR", "fr-FR", "1\u202f234,50\u00a0€"},
+		{"1234.5", "EUR", "de_DE", "1.234,50\u00a0€"},
+		{"1234.5", "USD", "xx", "$1,234.50"},
+		{"1234.5", "CHF", "en", "CHF\u00a01,234.50"},
+		{"1234.5", "CHF", "de", "1.234,50\u00a0CHF"},
+		{"1234", "JPY", "en", "¥1,234"},
+		{"1234.5", "JPY", "en", "¥1,235"},
+		{"1.2345", "KWD", "en", "KWD\u00a01.235"},
+		{"-4.8", "EUR", "en", "-€4.80"},
+		{"0", "EUR", "en", "€0.00"},
+		{"999", "EUR", "en", "€999.00"},
+		{"1000000", "GBP", "en", "£1,000,000.00"},
+		{"12.5", "", "en", "12.50"},
+	}
+	for _, tt := range tests {
+		m := mustParseMoney(tt.amount, tt.currency)
+		if got := m.Format(tt.locale); got != tt.want {
+			t.Errorf("%s in %s: got %q, want %q", m, tt.locale, got, tt.want)
+		}
+	}
+}
+
+// mustParseMoney is like ParseMoney but panics on invalid input
+func mustParseMoney(amount, currency string) Money {
+	m, err := ParseMoney(amount, currency)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
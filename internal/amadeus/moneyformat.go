@@ -0,0 +1,93 @@
+package amadeus
+
+import (
+	"strings"
+)
+
+// currencyMinorUnits lists the ISO 4217 currencies that do not have
+// two decimal places.
+var currencyMinorUnits = map[string]int32{
+	"BHD": 3, "CLP": 0, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0,
+	"KWD": 3, "OMR": 3, "TND": 3, "UGX": 0, "VND": 0, "XAF": 0, "XOF": 0,
+}
+
+// currencySymbols lists the symbols of common currencies.
+// Other currencies are formatted with their ISO code.
+var currencySymbols = map[string]string{
+	"EUR": "€", "USD": "$", "GBP": "£", "JPY": "¥", "INR": "₹", "KRW": "₩",
+}
+
+// numberFormat describes how a locale formats amounts.
+type numberFormat struct {
+	group, decimal string
+	// symbolFirst puts the currency before the amount, without space.
+	symbolFirst bool
+}
+
+// numberFormats maps languages (the first part of a locale such as "fr-FR") to formats.
+var numberFormats = map[string]numberFormat{
+	"en": {group: ",", decimal: ".", symbolFirst: true},
+	"fr": {group: "\u202f", decimal: ","},
+	"de": {group: ".", decimal: ","},
+	"es": {group: ".", decimal: ","},
+	"it": {group: ".", decimal: ","},
+	"nl": {group: ".", decimal: ","},
+	"pt": {group: ".", decimal: ","},
+}
+
+// minorUnits returns the number of decimal places of a currency.
+func minorUnits(currency string) int32 {
+	if n, ok := currencyMinorUnits[currency]; ok {
+		return n
+	}
+	return 2
+}
+
+// Format formats m for display in the given locale (a BCP 47 tag such
+// as "en-US" or "fr"), rounded to the minor unit of the currency:
+// "€1,234.50" in English, "1 234,50 €" in French. Unknown locales are
+// formatted like English.
+func (m Money) Format(locale string) string {
+	lang, _, _ := strings.Cut(strings.ToLower(locale), "-")
+	lang, _, _ = strings.Cut(lang, "_")
+	f, ok := numberFormats[lang]
+	if !ok {
+		f = numberFormats["en"]
+	}
+
+	s := m.Amount.Round(minorUnits(m.Currency)).String()
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, digit := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteString(f.group)
+		}
+		b.WriteRune(digit)
+	}
+	if fracPart != "" {
+		b.WriteString(f.decimal)
+		b.WriteString(fracPart)
+	}
+	s = b.String()
+
+	symbol, ok := currencySymbols[m.Currency]
+	if !ok {
+		symbol = m.Currency
+	}
+	switch {
+	case symbol == "":
+	case f.symbolFirst && ok:
+		s = symbol + s
+	case f.symbolFirst:
+		s = symbol + "\u00a0" + s
+	default:
+		s = s + "\u00a0" + symbol
+	}
+	if negative {
+		s = "-" + s
+	}
+	return s
+}
@@ -0,0 +1,97 @@
+package amadeus
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Environment selects one of the two Amadeus for Developers API environments.
+type Environment int
+
+const (
+	// Test is the free test environment with a limited, cached data set.
+	Test Environment = iota
+	// Production is the live environment. Bookings made here are real.
+	Production
+)
+
+// defaultTimeout is the timeout of the HTTP client that New creates
+// if no client is passed via WithHTTPClient.
+const defaultTimeout = 10 * time.Second
+
+// BaseURL returns the API base URL of the environment.
+func (e Environment) BaseURL() string {
+	if e == Production {
+		return "https://api.amadeus.com/v1"
+	}
+	return "https://test.api.amadeus.com/v1"
+}
+
+// String returns the name of the environment.
+func (e Environment) String() string {
+	if e == Production {
+		return "production"
+	}
+	return "test"
+}
+
+// Option configures a Client. Options are applied in the order
+// they are passed to New, so later options override earlier ones.
+type Option func(*Client)
+
+// WithEnvironment points the client at the test or the production environment.
+func WithEnvironment(e Environment) Option {
+	return func(c *Client) {
+		c.baseURL = e.BaseURL()
+		c.environment = e
+	}
+}
+
+// WithBaseURL sets an arbitrary API base URL, including the version path
+// (for example, "http://localhost:8080/v1"). This is useful for pointing
+// the client at a local stand-in server.
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = strings.TrimRight(baseURL, "/")
+	}
+}
+
+// WithHTTPClient sets the HTTP client that is used for all API calls,
+// including token requests. The client is shared, not copied, unless
+// WithTransport or WithTimeout is passed as well. A nil client means
+// the default one.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTransport sets the RoundTripper of the client's HTTP client.
+// It applies to a copy of the client passed with WithHTTPClient, which
+// is left unchanged, no matter in which order the options are passed.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.transport = rt
+	}
+}
+
+// WithTimeout sets the timeout of each HTTP request. Like WithTransport,
+// it applies to a copy of the client passed with WithHTTPClient.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+		c.timeoutSet = true
+	}
+}
+
+// WithCredentials sets the client ID and secret explicitly.
+// It is a shorthand for WithCredentialsProvider(StaticCredentials{...}).
+// Without this option, the client reads them from the environment
+// variables AMADEUS_CLIENT_ID and AMADEUS_CLIENT_SECRET.
+func WithCredentials(clientID, clientSecret string) Option {
+	return WithCredentialsProvider(StaticCredentials{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+	})
+}
@@ -0,0 +1,44 @@
+package amadeus
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHTTPClientOptions(t *testing.T) {
+	transport := &http.Transport{}
+	shared := &http.Client{Timeout: time.Minute}
+
+	tests := []struct {
+		name          string
+		opts          []Option
+		wantTransport http.RoundTripper
+		wantTimeout   time.Duration
+		wantShared    bool
+	}{
+		{"default", nil, nil, defaultTimeout, false},
+		{"nil client", []Option{WithHTTPClient(nil)}, nil, defaultTimeout, false},
+		{"nil client with transport", []Option{WithHTTPClient(nil), WithTransport(transport)}, transport, defaultTimeout, false},
+		{"shared client", []Option{WithHTTPClient(shared)}, nil, time.Minute, true},
+		{"transport before client", []Option{WithTransport(transport), WithHTTPClient(shared)}, transport, time.Minute, false},
+		{"timeout after client", []Option{WithHTTPClient(shared), WithTimeout(time.Second)}, nil, time.Second, false},
+		{"timeout before client", []Option{WithTimeout(time.Second), WithHTTPClient(shared)}, nil, time.Second, false},
+	}
+	for _, tt := range tests {
+		c := New(append([]Option{WithCredentials("id", "secret"), WithBaseURL("http://127.0.0.1:0")}, tt.opts...)...)
+		c.Close()
+		hc := c.httpClient
+		if hc.Transport != tt.wantTransport || hc.Timeout != tt.wantTimeout {
+			t.Errorf("%s: got transport %v and timeout %v, want %v and %v", tt.name, hc.Transport, hc.Timeout, tt.wantTransport, tt.wantTimeout)
+		}
+		if (hc == shared) != tt.wantShared {
+			t.Errorf("%s: client is shared = %v, want %v", tt.name, hc == shared, tt.wantShared)
+		}
+	}
+
+	// The shared client is never changed
+	if shared.Transport != nil || shared.Timeout != time.Minute {
+		t.Errorf("shared client was changed: transport %v, timeout %v", shared.Transport, shared.Timeout)
+	}
+}
@@ -0,0 +1,615 @@
+package amadeus
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FieldError reports an invalid field of a request. Field is the path
+// of the field in the request's JSON, such as "passengers[0].contacts.email".
+// The constructors and Validate methods in this file return one
+// FieldError per invalid field, joined with errors.Join.
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string {
+	return "amadeus: invalid " + e.Field + ": " + e.Reason
+}
+
+// FieldErrors returns all FieldErrors contained in err.
+func FieldErrors(err error) []*FieldError {
+	var fieldErrs []*FieldError
+	for _, e := range unjoin(err) {
+		var fe *FieldError
+		if errors.As(e, &fe) {
+			fieldErrs = append(fieldErrs, fe)
+		}
+	}
+	return fieldErrs
+}
+
+// invalid returns a FieldError for field.
+func invalid(field, format string, args ...any) error {
+	return &FieldError{Field: field, Reason: fmt.Sprintf(format, args...)}
+}
+
+// required returns a FieldError for field if value is empty.
+func required(field, value string) error {
+	if strings.TrimSpace(value) == "" {
+		return invalid(field, "is required")
+	}
+	return nil
+}
+
+// within prefixes the fields of all FieldErrors in err with the path
+// of the enclosing struct.
+func within(prefix string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var errs []error
+	for _, e := range unjoin(err) {
+		var fe *FieldError
+		if errors.As(e, &fe) {
+			e = &FieldError{Field: prefix + "." + fe.Field, Reason: fe.Reason}
+		}
+		errs = append(errs, e)
+	}
+	return errors.Join(errs...)
+}
+
+// unjoin splits an error created by errors.Join into its parts,
+// including the parts of nested joins.
+func unjoin(err error) []error {
+	if err == nil {
+		return nil
+	}
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, unjoin(e)...)
+	}
+	return errs
+}
+
+// NewAddress returns a validated postal address.
+func NewAddress(line, zip, cityName, countryCode string) (Address, error) {
+	a := Address{
+		Line:        strings.TrimSpace(line),
+		Zip:         strings.TrimSpace(zip),
+		CountryCode: strings.ToUpper(strings.TrimSpace(countryCode)),
+		CityName:    strings.TrimSpace(cityName),
+	}
+	return a, a.Validate()
+}
+
+// Validate checks that the address is complete and that CountryCode
+// is a two-letter ISO 3166-1 code.
+func (a Address) Validate() error {
+	errs := []error{
+		required("line", a.Line),
+		required("zip", a.Zip),
+		required("cityName", a.CityName),
+	}
+	if !isLetters(a.CountryCode, 2) {
+		errs = append(errs, invalid("countryCode", "must be a two-letter country code"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks that the contacts contain a plausible email address
+// and phone number.
+func (c Contacts) Validate() error {
+	var errs []error
+	if addr, err := mail.ParseAddress(c.Email); err != nil || addr.Address != c.Email {
+		errs = append(errs, invalid("email", "must be an email address"))
+	}
+	digits := 0
+	for _, r := range c.PhoneNumber {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case strings.ContainsRune("+-() ", r):
+		default:
+			errs = append(errs, invalid("phoneNumber", "may only contain digits, spaces and + - ( )"))
+			return errors.Join(errs...)
+		}
+	}
+	if digits < 5 {
+		errs = append(errs, invalid("phoneNumber", "must have at least 5 digits"))
+	}
+	return errors.Join(errs...)
+}
+
+// NewPassenger returns a validated passenger for a booking.
+func NewPassenger(title, firstName, lastName string, contacts Contacts, billingAddress Address) (Passenger, error) {
+	p := Passenger{
+		Title:          strings.ToUpper(strings.TrimSpace(title)),
+		FirstName:      strings.TrimSpace(firstName),
+		LastName:       strings.TrimSpace(lastName),
+		Contacts:       contacts,
+		BillingAddress: billingAddress,
+	}
+	return p, p.Validate()
+}
+
+// Validate checks the passenger's name, contacts and billing address.
+func (p Passenger) Validate() error {
+	return errors.Join(
+		required("firstName", p.FirstName),
+		required("lastName", p.LastName),
+		within("contacts", p.Contacts.Validate()),
+		within("billingAddress", p.BillingAddress.Validate()),
+	)
+}
+
+// NewCreditCard returns a validated credit card. Spaces and dashes in
+// the number are removed, and an expiry date in the form "MM/YY" is
+// converted to the "MMYY" form that Amadeus expects.
+func NewCreditCard(number, holderName, vendorCode, expiryDate, cvv string) (CreditCard, error) {
+	c := CreditCard{
+		Number:     strings.NewReplacer(" ", "", "-", "").Replace(number),
+		HolderName: strings.ToUpper(strings.TrimSpace(holderName)),
+		VendorCode: strings.ToUpper(strings.TrimSpace(vendorCode)),
+		ExpiryDate: strings.ReplaceAll(strings.TrimSpace(expiryDate), "/", ""),
+		Cvv:        strings.TrimSpace(cvv),
+	}
+	return c, c.Validate()
+}
+
+// Validate checks the card number's length and Luhn checksum, and
+// that the card has not expired.
+func (c CreditCard) Validate() error {
+	errs := []error{required("holderName", c.HolderName)}
+	if len(c.Number) < 12 || len(c.Number) > 19 || !isDigits(c.Number) || !luhn(c.Number) {
+		errs = append(errs, invalid("number", "is not a valid card number"))
+	}
+	if !isLetters(c.VendorCode, 2) {
+		errs = append(errs, invalid("vendorCode", "must be a two-letter card vendor code"))
+	}
+	if expires, ok := parseExpiryDate(c.ExpiryDate); !ok {
+		errs = append(errs, invalid("expiryDate", "must be in the form MMYY"))
+	} else if !time.Now().Before(expires) {
+		errs = append(errs, invalid("expiryDate", "the card has expired"))
+	}
+	if (len(c.Cvv) != 3 && len(c.Cvv) != 4) || !isDigits(c.Cvv) {
+		errs = append(errs, invalid("cvv", "must have 3 or 4 digits"))
+	}
+	return errors.Join(errs...)
+}
+
+// parseExpiryDate parses a card expiry date "MMYY" and returns the
+// first moment after the card's last valid day.
+func parseExpiryDate(s string) (time.Time, bool) {
+	if len(s) != 4 || !isDigits(s) {
+		return time.Time{}, false
+	}
+	month, _ := strconv.Atoi(s[:2])
+	year, _ := strconv.Atoi(s[2:])
+	if month < 1 || month > 12 {
+		return time.Time{}, false
+	}
+	return time.Date(2000+year, time.Month(month)+1, 1, 0, 0, 0, 0, time.UTC), true
+}
+
+// luhn reports whether a string of digits has a valid Luhn checksum.
+func luhn(number string) bool {
+	sum := 0
+	for i := range number {
+		d := int(number[len(number)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// CreditCardPayment returns a payment by credit card.
+func CreditCardPayment(card CreditCard) Payment {
+	return Payment{MethodOfPayment: PaymentCreditCard, CreditCard: &card}
+}
+
+// Validate checks that a payment method is set, and the credit card
+// if the payment is made by credit card.
+func (p Payment) Validate() error {
+	if err := required("methodOfPayment", string(p.MethodOfPayment)); err != nil {
+		return err
+	}
+	if !p.MethodOfPayment.Known() {
+		return invalid("methodOfPayment", "unknown payment method %q", p.MethodOfPayment)
+	}
+	if p.MethodOfPayment != PaymentCreditCard {
+		return nil
+	}
+	if p.CreditCard == nil {
+		return invalid("creditCard", "is required for payment by credit card")
+	}
+	return within("creditCard", p.CreditCard.Validate())
+}
+
+// NewBookingParameters returns the request body for booking a transfer
+// for the given passengers. The first passenger is the lead passenger.
+// Optional sections, such as a note or connected segments, can be set
+// on the result's Data.
+func NewBookingParameters(payment Payment, passengers ...Passenger) (BookingParameters, error) {
+	p := BookingParameters{Data: BookingData{
+		Passengers: passengers,
+		Payment:    payment,
+	}}
+	return p, p.Validate()
+}
+
+// Validate checks the passengers, the payment and the connected
+// segments of a booking.
+func (p BookingParameters) Validate() error {
+	var errs []error
+	if len(p.Data.Passengers) == 0 {
+		errs = append(errs, invalid("passengers", "at least one passenger is required"))
+	}
+	for i, passenger := range p.Data.Passengers {
+		errs = append(errs, within(fmt.Sprintf("passengers[%d]", i), passenger.Validate()))
+	}
+	errs = append(errs, within("payment", p.Data.Payment.Validate()))
+	if p.Data.StartConnectedSegment != nil {
+		errs = append(errs, within("startConnectedSegment", p.Data.StartConnectedSegment.Validate()))
+	}
+	if p.Data.EndConnectedSegment != nil {
+		errs = append(errs, within("endConnectedSegment", p.Data.EndConnectedSegment.Validate()))
+	}
+	return errors.Join(errs...)
+}
+
+// NewStopOver returns a validated stop-over at the given address, where
+// the vehicle waits for the given time. Its sequence number is set by
+// SearchParameters.AddStopOver.
+func NewStopOver(address Address, wait time.Duration) (StopOver, error) {
+	s := StopOver{
+		Duration:    formatISODuration(wait),
+		AddressLine: address.Line,
+		ZipCode:     address.Zip,
+		CityName:    address.CityName,
+		CountryCode: address.CountryCode,
+	}
+	if address.Latitude != 0 || address.Longitude != 0 {
+		s.GeoCode = fmt.Sprintf("%g,%g", address.Latitude, address.Longitude)
+	}
+	if wait < 0 {
+		return s, invalid("duration", "must not be negative")
+	}
+	return s, s.Validate()
+}
+
+// Wait returns the waiting time at the stop-over.
+func (s StopOver) Wait() (time.Duration, error) {
+	return parseISODuration(s.Duration)
+}
+
+// Validate checks that the stop-over has a location and a valid duration.
+func (s StopOver) Validate() error {
+	var errs []error
+	if s.AddressLine == "" && s.GeoCode == "" {
+		errs = append(errs, invalid("addressLine", "an address or geo code is required"))
+	}
+	if s.CountryCode != "" && !isLetters(s.CountryCode, 2) {
+		errs = append(errs, invalid("countryCode", "must be a two-letter country code"))
+	}
+	if s.GeoCode != "" {
+		if _, _, ok := parseGeoCode(s.GeoCode); !ok {
+			errs = append(errs, invalid("geoCode", "must be latitude,longitude"))
+		}
+	}
+	if s.Duration != "" {
+		if _, err := s.Wait(); err != nil {
+			errs = append(errs, invalid("duration", "%v", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AddStopOver validates a stop-over and appends it to the search,
+// numbering it after the existing ones.
+func (p *SearchParameters) AddStopOver(s StopOver) error {
+	if err := s.Validate(); err != nil {
+		return within(fmt.Sprintf("stopOvers[%d]", len(p.StopOvers)), err)
+	}
+	s.SequenceNumber = len(p.StopOvers) + 1
+	p.StopOvers = append(p.StopOvers, s)
+	return nil
+}
+
+// AddPassenger validates a passenger of the given type and age and
+// adds it to the search, updating Passengers. Age is optional for adults.
+func (p *SearchParameters) AddPassenger(typeCode PassengerType, age int) error {
+	c := PassengerCharacteristic{PassengerTypeCode: typeCode, Age: age}
+	if err := c.Validate(); err != nil {
+		return within(fmt.Sprintf("passengerCharacteristics[%d]", len(p.PassengerCharacteristics)), err)
+	}
+	p.PassengerCharacteristics = append(p.PassengerCharacteristics, c)
+	p.Passengers = len(p.PassengerCharacteristics)
+	return nil
+}
+
+// Validate checks that the passenger type is known and that the age,
+// if set, matches it.
+func (c PassengerCharacteristic) Validate() error {
+	switch {
+	case !c.PassengerTypeCode.Known():
+		return invalid("passengerTypeCode", "unknown passenger type %q", c.PassengerTypeCode)
+	case c.Age < 0:
+		return invalid("age", "must not be negative")
+	case c.PassengerTypeCode == PassengerChild && c.Age >= childAgeLimit:
+		return invalid("age", "children must be under %d", childAgeLimit)
+	case c.PassengerTypeCode == PassengerAdult && c.Age != 0 && c.Age < childAgeLimit:
+		return invalid("age", "adults must be %d or over", childAgeLimit)
+	}
+	return nil
+}
+
+// Validate checks the search for missing or impossible combinations of
+// parameters, so that they are rejected before a request is sent. It
+// does not check whether the locations exist or offers are available.
+func (p SearchParameters) Validate() error {
+	var errs []error
+
+	startAddress := p.StartAddressLine != "" || p.StartGeoCode != ""
+	endAddress := p.EndAddressLine != "" || p.EndGeoCode != ""
+	switch {
+	case p.StartLocationCode == "" && !startAddress:
+		errs = append(errs, invalid("startLocationCode", "a start airport or address is required"))
+	case p.StartLocationCode != "" && startAddress:
+		errs = append(errs, invalid("startLocationCode", "the transfer cannot start at both an airport and an address"))
+	}
+	switch {
+	case p.EndLocationCode == "" && !endAddress && p.TransferType != TransferHourly:
+		errs = append(errs, invalid("endLocationCode", "an end airport or address is required"))
+	case p.EndLocationCode != "" && endAddress:
+		errs = append(errs, invalid("endLocationCode", "the transfer cannot end at both an airport and an address"))
+	}
+	if p.StartLocationCode != "" && !isLetters(p.StartLocationCode, 3) {
+		errs = append(errs, invalid("startLocationCode", "must be a three-letter IATA code"))
+	}
+	if p.EndLocationCode != "" && !isLetters(p.EndLocationCode, 3) {
+		errs = append(errs, invalid("endLocationCode", "must be a three-letter IATA code"))
+	}
+	if p.StartLocationCode != "" && p.StartLocationCode == p.EndLocationCode {
+		errs = append(errs, invalid("endLocationCode", "must differ from the start airport"))
+	}
+	if startAddress {
+		errs = append(errs, validateAddressParams("start", p.StartAddressLine, p.StartCityName, p.StartCountryCode, p.StartGeoCode)...)
+	}
+	if endAddress {
+		errs = append(errs, validateAddressParams("end", p.EndAddressLine, p.EndCityName, p.EndCountryCode, p.EndGeoCode)...)
+	}
+
+	if p.StartDateTime.IsZero() {
+		errs = append(errs, invalid("startDateTime", "is required"))
+	}
+
+	if p.TransferType != "" && !p.TransferType.Known() {
+		errs = append(errs, invalid("transferType", "unknown transfer type %q", p.TransferType))
+	}
+	if (p.TransferType == TransferAirportExpress || p.TransferType == TransferAirportBus) &&
+		p.StartLocationCode == "" && p.EndLocationCode == "" {
+		errs = append(errs, invalid("transferType", "%s transfers must start or end at an airport", p.TransferType))
+	}
+
+	if p.Passengers < 0 {
+		errs = append(errs, invalid("passengers", "must not be negative"))
+	}
+	if len(p.PassengerCharacteristics) > 0 && p.Passengers != len(p.PassengerCharacteristics) {
+		errs = append(errs, invalid("passengers", "must match the number of passenger characteristics"))
+	}
+	for i, c := range p.PassengerCharacteristics {
+		errs = append(errs, within(fmt.Sprintf("passengerCharacteristics[%d]", i), c.Validate()))
+	}
+
+	for i, s := range p.StopOvers {
+		errs = append(errs, within(fmt.Sprintf("stopOvers[%d]", i), s.Validate()))
+	}
+
+	if seg := p.StartConnectedSegment; seg != nil {
+		errs = append(errs, within("startConnectedSegment", seg.Validate()))
+		// The transfer picks the passengers up where the segment arrives.
+		if seg.Arrival != nil && seg.Arrival.IataCode != "" && p.StartLocationCode != "" && seg.Arrival.IataCode != p.StartLocationCode {
+			errs = append(errs, invalid("startConnectedSegment.arrival.iataCode", "must be the start airport %s", p.StartLocationCode))
+		}
+		if seg.Arrival != nil && !seg.Arrival.LocalDateTime.IsZero() && !p.StartDateTime.IsZero() && p.StartDateTime.Before(seg.Arrival.LocalDateTime) {
+			errs = append(errs, invalid("startDateTime", "must not be before the arrival of the connected segment"))
+		}
+	}
+	if seg := p.EndConnectedSegment; seg != nil {
+		errs = append(errs, within("endConnectedSegment", seg.Validate()))
+		// The transfer drops the passengers off where the segment departs.
+		if seg.Departure != nil && seg.Departure.IataCode != "" && p.EndLocationCode != "" && seg.Departure.IataCode != p.EndLocationCode {
+			errs = append(errs, invalid("endConnectedSegment.departure.iataCode", "must be the end airport %s", p.EndLocationCode))
+		}
+		if seg.Departure != nil && !seg.Departure.LocalDateTime.IsZero() && !p.StartDateTime.IsZero() && !p.StartDateTime.Before(seg.Departure.LocalDateTime) {
+			errs = append(errs, invalid("startDateTime", "must be before the departure of the connected segment"))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateAddressParams checks the address fields of a search with the
+// given prefix ("start" or "end").
+func validateAddressParams(prefix, line, cityName, countryCode, geoCode string) []error {
+	var errs []error
+	if line != "" && cityName == "" {
+		errs = append(errs, invalid(prefix+"CityName", "is required with an address"))
+	}
+	if !isLetters(countryCode, 2) {
+		errs = append(errs, invalid(prefix+"CountryCode", "must be a two-letter country code"))
+	}
+	if geoCode != "" {
+		if _, _, ok := parseGeoCode(geoCode); !ok {
+			errs = append(errs, invalid(prefix+"GeoCode", "must be latitude,longitude"))
+		}
+	}
+	return errs
+}
+
+// parseGeoCode parses a geo code "latitude,longitude" and checks that
+// both are finite and in range.
+func parseGeoCode(s string) (lat, lng float64, ok bool) {
+	latStr, lngStr, found := strings.Cut(s, ",")
+	if !found {
+		return 0, 0, false
+	}
+	lat, errLat := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	lng, errLng := strconv.ParseFloat(strings.TrimSpace(lngStr), 64)
+	if errLat != nil || errLng != nil {
+		return 0, 0, false
+	}
+	// ParseFloat accepts "NaN" and "Inf", which compare false with any
+	// bound, so they are rejected before the range checks.
+	if math.IsNaN(lat) || math.IsNaN(lng) || math.IsInf(lat, 0) || math.IsInf(lng, 0) {
+		return 0, 0, false
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return 0, 0, false
+	}
+	return lat, lng, true
+}
+
+// NewFlightSegment returns a validated flight that connects to a
+// transfer. Either departure or arrival may be nil, but not both.
+func NewFlightSegment(flightNumber string, departure, arrival *SegmentEndpoint) (ConnectedSegment, error) {
+	s := ConnectedSegment{
+		TransportationType:   TransportationFlight,
+		TransportationNumber: strings.ToUpper(strings.ReplaceAll(flightNumber, " ", "")),
+		Departure:            departure,
+		Arrival:              arrival,
+	}
+	return s, s.Validate()
+}
+
+// Validate checks that the segment is identified and that its
+// departure comes before its arrival.
+func (s ConnectedSegment) Validate() error {
+	errs := []error{
+		required("transportationType", string(s.TransportationType)),
+		required("transportationNumber", s.TransportationNumber),
+	}
+	if s.TransportationType != "" && !s.TransportationType.Known() {
+		errs = append(errs, invalid("transportationType", "unknown transportation type %q", s.TransportationType))
+	}
+	if s.Departure == nil && s.Arrival == nil {
+		errs = append(errs, invalid("arrival", "a departure or arrival is required"))
+	}
+	if s.Departure != nil {
+		errs = append(errs, within("departure", s.Departure.Validate()))
+	}
+	if s.Arrival != nil {
+		errs = append(errs, within("arrival", s.Arrival.Validate()))
+	}
+	if s.Departure != nil && s.Arrival != nil &&
+		!s.Departure.LocalDateTime.IsZero() && !s.Arrival.LocalDateTime.IsZero() &&
+		!s.Departure.LocalDateTime.Before(s.Arrival.LocalDateTime) {
+		errs = append(errs, invalid("arrival", "must be after the departure"))
+	}
+	return errors.Join(errs...)
+}
+
+// Validate checks that the endpoint has a station code and a time.
+func (e SegmentEndpoint) Validate() error {
+	var errs []error
+	if e.IataCode == "" && e.UicCode == "" {
+		errs = append(errs, invalid("iataCode", "an IATA or UIC code is required"))
+	}
+	if e.IataCode != "" && !isLetters(e.IataCode, 3) {
+		errs = append(errs, invalid("iataCode", "must be a three-letter IATA code"))
+	}
+	if e.LocalDateTime.IsZero() {
+		errs = append(errs, invalid("localDateTime", "is required"))
+	}
+	return errors.Join(errs...)
+}
+
+// formatISODuration formats d as an ISO 8601 duration, such as "PT1H30M".
+// Amadeus only accepts hours and minutes, so d is rounded to minutes.
+func formatISODuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	h, m := int(d/time.Hour), int(d%time.Hour/time.Minute)
+	switch {
+	case h > 0 && m > 0:
+		return fmt.Sprintf("PT%dH%dM", h, m)
+	case h > 0:
+		return fmt.Sprintf("PT%dH", h)
+	default:
+		return fmt.Sprintf("PT%dM", m)
+	}
+}
+
+// parseISODuration parses an ISO 8601 duration without date part,
+// such as "PT1H30M" or "PT45S". Each of the units H, M and S may appear
+// once, in that order, and only the last one may have a fraction.
+func parseISODuration(s string) (time.Duration, error) {
+	const units = "HMS"
+	bad := fmt.Errorf("%q is not an ISO 8601 duration such as PT20M", s)
+	rest, ok := strings.CutPrefix(s, "PT")
+	if !ok || rest == "" {
+		return 0, bad
+	}
+	var d time.Duration
+	next := 0 // index in units of the first unit that may follow
+	for rest != "" {
+		i := strings.IndexAny(rest, units)
+		if i <= 0 {
+			return 0, bad
+		}
+		u := strings.IndexByte(units, rest[i])
+		if u < next {
+			return 0, bad
+		}
+		next = u + 1
+		whole, frac, hasFrac := strings.Cut(rest[:i], ".")
+		if !isDigits(whole) || hasFrac && (!isDigits(frac) || i+1 < len(rest)) {
+			return 0, bad
+		}
+		n, err := strconv.ParseFloat(rest[:i], 64)
+		unit := []time.Duration{time.Hour, time.Minute, time.Second}[u]
+		if err != nil || n*float64(unit) > float64(math.MaxInt64-d) {
+			return 0, bad
+		}
+		d += time.Duration(n * float64(unit))
+		rest = rest[i+1:]
+	}
+	return d, nil
+}
+
+// isDigits reports whether s consists of ASCII digits only.
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// isLetters reports whether s consists of n uppercase ASCII letters.
+func isLetters(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
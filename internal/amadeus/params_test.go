@@ -0,0 +1,259 @@
+package amadeus
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseGeoCode(t *testing.T) {
+	tests := []struct {
+		in       string
+		lat, lng float64
+		ok       bool
+	}{
+		{"48.8566,2.3522", 48.8566, 2.3522, true},
+		{" -33.8688 , 151.2093 ", -33.8688, 151.2093, true},
+		{"90,180", 90, 180, true},
+		{"-90,-180", -90, -180, true},
+		{"90.1,0", 0, 0, false},
+		{"0,-180.5", 0, 0, false},
+		{"NaN,NaN", 0, 0, false},
+		{"0,NaN", 0, 0, false},
+		{"Inf,0", 0, 0, false},
+		{"0,-Infinity", 0, 0, false},
+		{"48.8566", 0, 0, false},
+		{"48.8566;2.3522", 0, 0, false},
+		{"north,east", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		lat, lng, ok := parseGeoCode(tt.in)
+		if ok != tt.ok || lat != tt.lat || lng != tt.lng {
+			t.Errorf("parseGeoCode(%q) = %v, %v, %v, want %v, %v, %v", tt.in, lat, lng, ok, tt.lat, tt.lng, tt.ok)
+		}
+	}
+}
+
+func TestSearchParametersValidate(t *testing.T) {
+	start, err := ParseLocalDateTime("2030-06-01T10:30:00", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// valid returns a search from CDG to an address, changed by f
+	valid := func(f func(p *SearchParameters)) SearchParameters {
+		p := SearchParameters{
+			StartLocationCode: "CDG",
+			EndAddressLine:    "Avenue Anatole France 5",
+			EndCityName:       "Paris",
+			EndCountryCode:    "FR",
+			EndGeoCode:        "48.859466,2.2976965",
+			TransferType:      TransferPrivate,
+			StartDateTime:     start,
+			Passengers:        2,
+		}
+		if f != nil {
+			f(&p)
+		}
+		return p
+	}
+	tests := []struct {
+		name   string
+		params SearchParameters
+		fields []string
+	}{
+		{"valid", valid(nil), nil},
+		{"airport to airport", valid(func(p *SearchParameters) {
+			p.EndAddressLine, p.EndCityName, p.EndCountryCode, p.EndGeoCode = "", "", "", ""
+			p.EndLocationCode = "ORY"
+		}), nil},
+		{"hourly without end", valid(func(p *SearchParameters) {
+			p.EndAddressLine, p.EndCityName, p.EndCountryCode, p.EndGeoCode = "", "", "", ""
+			p.TransferType = TransferHourly
+		}), nil},
+		{"no start", valid(func(p *SearchParameters) { p.StartLocationCode = "" }), []string{"startLocationCode"}},
+		{"no end", valid(func(p *SearchParameters) {
+			p.EndAddressLine, p.EndCityName, p.EndCountryCode, p.EndGeoCode = "", "", "", ""
+		}), []string{"endLocationCode"}},
+		{"airport and address", valid(func(p *SearchParameters) { p.EndLocationCode = "ORY" }), []string{"endLocationCode"}},
+		{"bad airport code", valid(func(p *SearchParameters) { p.StartLocationCode = "CD1" }), []string{"startLocationCode"}},
+		{"same airports", valid(func(p *SearchParameters) {
+			p.EndAddressLine, p.EndCityName, p.EndCountryCode, p.EndGeoCode = "", "", "", ""
+			p.EndLocationCode = "CDG"
+		}), []string{"endLocationCode"}},
+		{"address without city", valid(func(p *SearchParameters) { p.EndCityName = "" }), []string{"endCityName"}},
+		{"bad country", valid(func(p *SearchParameters) { p.EndCountryCode = "FRA" }), []string{"endCountryCode"}},
+		{"geo code out of range", valid(func(p *SearchParameters) { p.EndGeoCode = "148.85,2.29" }), []string{"endGeoCode"}},
+		{"geo code NaN", valid(func(p *SearchParameters) { p.EndGeoCode = "NaN,NaN" }), []string{"endGeoCode"}},
+		{"no date", valid(func(p *SearchParameters) { p.StartDateTime = LocalDateTime{} }), []string{"startDateTime"}},
+		{"unknown transfer type", valid(func(p *SearchParameters) { p.TransferType = "LIMO" }), []string{"transferType"}},
+		{"bus without airport", valid(func(p *SearchParameters) {
+			p.StartLocationCode = ""
+			p.StartAddressLine, p.StartCityName, p.StartCountryCode = "Rue de Rivoli 10", "Paris", "FR"
+			p.TransferType = TransferAirportBus
+		}), []string{"transferType"}},
+		{"negative passengers", valid(func(p *SearchParameters) { p.Passengers = -1 }), []string{"passengers"}},
+		{"passenger count mismatch", valid(func(p *SearchParameters) {
+			p.PassengerCharacteristics = []PassengerCharacteristic{{PassengerTypeCode: PassengerAdult}}
+		}), []string{"passengers"}},
+		{"several errors", valid(func(p *SearchParameters) {
+			p.StartLocationCode = "X"
+			p.EndGeoCode = "Inf,0"
+			p.Passengers = -1
+		}), []string{"startLocationCode", "endGeoCode", "passengers"}},
+	}
+	for _, tt := range tests {
+		var fields []string
+		for _, fe := range FieldErrors(tt.params.Validate()) {
+			fields = append(fields, fe.Field)
+		}
+		if !slices.Equal(fields, tt.fields) {
+			t.Errorf("%s: got invalid fields %v, want %v", tt.name, fields, tt.fields)
+		}
+	}
+}
+
+func TestParseISODuration(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "PT20M", want: 20 * time.Minute},
+		{in: "PT1H30M", want: 90 * time.Minute},
+		{in: "PT1H30M15S", want: 90*time.Minute + 15*time.Second},
+		{in: "PT45S", want: 45 * time.Second},
+		{in: "PT0M", want: 0},
+		{in: "PT1.5H", want: 90 * time.Minute},
+		{in: "PT1H0.5M", want: time.Hour + 30*time.Second},
+		{in: "PT", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "P1D", wantErr: true},
+		{in: "PT20", wantErr: true},
+		{in: "PTM", wantErr: true},
+		{in: "PT-5M", wantErr: true},
+		{in: "PT+5M", wantErr: true},
+		{in: "PT1e2M", wantErr: true},
+		{in: "PTNaNM", wantErr: true},
+		{in: "PT1.M", wantErr: true},
+		{in: "pt20m", wantErr: true},
+		// Each unit at most once, in the order H, M, S
+		{in: "PT1H1H", wantErr: true},
+		{in: "PT30M1H", wantErr: true},
+		{in: "PT10S5M", wantErr: true},
+		// Only the last unit may have a fraction
+		{in: "PT1.5H30M", wantErr: true},
+		{in: "PT9999999999H", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseISODuration(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseISODuration(%q) = %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseISODuration(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+
+	// formatISODuration and parseISODuration round-trip
+	for _, d := range []time.Duration{0, 20 * time.Minute, 2 * time.Hour, 90 * time.Minute} {
+		if got, err := parseISODuration(formatISODuration(d)); err != nil || got != d {
+			t.Errorf("round trip of %v: got %v, %v", d, got, err)
+		}
+	}
+}
+
+func TestLuhn(t *testing.T) {
+	tests := []struct {
+		number string
+		want   bool
+	}{
+		{"4111111111111111", true},
+		{"5555555555554444", true},
+		{"378282246310005", true},
+		{"79927398713", true},
+		{"4111111111111112", false},
+		{"79927398710", false},
+		{"0", true},
+	}
+	for _, tt := range tests {
+		if got := luhn(tt.number); got != tt.want {
+			t.Errorf("luhn(%q) = %v, want %v", tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestParseExpiryDate(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   time.Time
+		wantOK bool
+	}{
+		// Valid until the end of the month
+		{"0126", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), true},
+		{"1230", time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"0299", time.Date(2099, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"0026", time.Time{}, false},
+		{"1326", time.Time{}, false},
+		{"01/26", time.Time{}, false},
+		{"126", time.Time{}, false},
+		{"ab26", time.Time{}, false},
+		{"", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseExpiryDate(tt.in)
+		if ok != tt.wantOK || !got.Equal(tt.want) {
+			t.Errorf("parseExpiryDate(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestNewBookingParameters(t *testing.T) {
+	nextYear := time.Now().AddDate(1, 0, 0).Format("0106")
+	lastYear := time.Now().AddDate(-1, 0, 0).Format("01/06")
+	passenger := Passenger{
+		Title: "MR", FirstName: "Jean", LastName: "Dupont",
+		Contacts:       Contacts{Email: "jean@example.com", PhoneNumber: "+33 1 23 45 67 89"},
+		BillingAddress: Address{Line: "Rue de Rivoli 10", Zip: "75001", CityName: "Paris", CountryCode: "FR"},
+	}
+	card := func(number, expiry, cvv string) Payment {
+		c, _ := NewCreditCard(number, "Jean Dupont", "vi", expiry, cvv)
+		return CreditCardPayment(c)
+	}
+	tests := []struct {
+		name       string
+		payment    Payment
+		passengers []Passenger
+		fields     []string
+	}{
+		{"valid", card("4111 1111 1111 1111", nextYear, "123"), []Passenger{passenger}, nil},
+		{"expiry with slash", card("4111-1111-1111-1111", nextYear[:2]+"/"+nextYear[2:], "1234"), []Passenger{passenger}, nil},
+		{"no passengers", card("4111111111111111", nextYear, "123"), nil, []string{"passengers"}},
+		{"bad checksum", card("4111111111111112", nextYear, "123"), []Passenger{passenger}, []string{"payment.creditCard.number"}},
+		{"expired card", card("4111111111111111", lastYear, "123"), []Passenger{passenger}, []string{"payment.creditCard.expiryDate"}},
+		{"short CVV", card("4111111111111111", nextYear, "12"), []Passenger{passenger}, []string{"payment.creditCard.cvv"}},
+		{"no card", Payment{MethodOfPayment: PaymentCreditCard}, []Passenger{passenger}, []string{"payment.creditCard"}},
+		{"no payment method", Payment{}, []Passenger{passenger}, []string{"payment.methodOfPayment"}},
+		{"second passenger without email", card("4111111111111111", nextYear, "123"), []Passenger{passenger, func() Passenger {
+			p := passenger
+			p.Contacts.Email = "jean"
+			return p
+		}()}, []string{"passengers[1].contacts.email"}},
+	}
+	for _, tt := range tests {
+		p, err := NewBookingParameters(tt.payment, tt.passengers...)
+		var fields []string
+		for _, fe := range FieldErrors(err) {
+			fields = append(fields, fe.Field)
+		}
+		if !slices.Equal(fields, tt.fields) {
+			t.Errorf("%s: got invalid fields %v, want %v", tt.name, fields, tt.fields)
+		}
+		if len(p.Data.Passengers) != len(tt.passengers) {
+			t.Errorf("%s: got %d passengers, want %d", tt.name, len(p.Data.Passengers), len(tt.passengers))
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package amadeus
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The Amadeus for Developers quotas: the test environment accepts
+// 10 requests per second, the production environment 40, in both
+// cases with no more than one request per 1/rate seconds.
+const (
+	testRateLimit       = 10
+	productionRateLimit = 40
+)
+
+// WithRateLimit limits the rate of all requests of the client,
+// including token requests, to rps requests per second with bursts
+// of up to burst requests. Calls over the limit wait for their turn
+// instead of failing. An rps of 0 or less disables the limit.
+//
+// Without this option, the client applies the quota of the
+// environment it talks to (see WithEnvironment).
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		c.limiter = newLimiter(rps, burst)
+		c.rateLimitSet = true
+	}
+}
+
+// defaultLimiter returns a limiter for the quota of the environment.
+func (e Environment) defaultLimiter() *limiter {
+	if e == Production {
+		return newLimiter(productionRateLimit, 1)
+	}
+	return newLimiter(testRateLimit, 1)
+}
+
+// WithEndpointRateLimit adds a limit for requests whose path starts with
+// pathPrefix, relative to the base URL (for example,
+// "/shopping/transfer-offers"). It applies in addition to the limit
+// set by WithRateLimit.
+func WithEndpointRateLimit(pathPrefix string, rps float64, burst int) Option {
+	return func(c *Client) {
+		if c.endpointLimiters == nil {
+			c.endpointLimiters = map[string]*limiter{}
+		}
+		c.endpointLimiters[pathPrefix] = newLimiter(rps, burst)
+	}
+}
+
+// waitForRateLimit blocks until the request to path may be sent
+// according to the client's rate limits, or until ctx is done.
+func (c *Client) waitForRateLimit(ctx context.Context, path string) error {
+	if err := c.limiter.wait(ctx); err != nil {
+		return err
+	}
+	path, _, _ = strings.Cut(path, "?")
+	for prefix, l := range c.endpointLimiters {
+		if strings.HasPrefix(path, prefix) {
+			if err := l.wait(ctx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// limiter is a token bucket. It holds up to burst tokens and is
+// refilled at rate tokens per second. Each request takes one token.
+// A nil *limiter does not limit anything.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	// reserved counts the reservations, so that cancel can tell
+	// whether a reservation is the newest one.
+	reserved uint64
+}
+
+// newLimiter returns a full token bucket, or nil if rate is 0 or less.
+func newLimiter(rate float64, burst int) *limiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// wait takes a token from the bucket, waiting for the bucket to refill
+// if necessary. Concurrent callers are served in the order in which
+// they call wait. If ctx is done first, wait returns the context's
+// error, and gives the token back if no caller is queued behind it.
+func (l *limiter) wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	delay, n := l.reserve(time.Now())
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel(n)
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token at time now and returns how long the caller
+// has to wait until the token becomes valid, and the number of the
+// reservation for cancel. The bucket can go into debt, which queues
+// callers behind each other.
+func (l *limiter) reserve(now time.Time) (time.Duration, uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.After(l.last) {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+	}
+
+	l.tokens--
+	l.reserved++
+	if l.tokens >= 0 {
+		return 0, l.reserved
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second)), l.reserved
+}
+
+// cancel gives back the token of reservation n if it was not used.
+// Callers that reserved after n have been given delays that count on
+// n's token, so the token is only given back if n is the newest
+// reservation; otherwise the bucket could exceed its rate. The
+// reservation before n then becomes the newest one.
+func (l *limiter) cancel(n uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n != l.reserved {
+		return
+	}
+	l.reserved--
+	l.tokens++
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
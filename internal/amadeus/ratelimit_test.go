@@ -0,0 +1,115 @@
+package amadeus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiterReserve(t *testing.T) {
+	l := newLimiter(10, 3)
+	start := l.last
+	// at returns the time d after the limiter was created
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	steps := []struct {
+		name   string
+		at     time.Duration
+		cancel bool // cancel the reservation, or the one before it if not the newest
+		want   time.Duration
+	}{
+		// A full bucket serves a burst of 3 at once
+		{name: "burst 1", at: 0, want: 0},
+		{name: "burst 2", at: 0, want: 0},
+		{name: "burst 3", at: 0, want: 0},
+		// Then one request per 100ms, queued behind each other
+		{name: "queued 1", at: 0, want: 100 * time.Millisecond},
+		{name: "queued 2", at: 0, want: 200 * time.Millisecond},
+		{name: "steady", at: 250 * time.Millisecond, want: 50 * time.Millisecond},
+		// After a pause the bucket is full again, but no fuller
+		{name: "after pause 1", at: 10 * time.Second, want: 0},
+		{name: "after pause 2", at: 10 * time.Second, want: 0},
+		{name: "after pause 3", at: 10 * time.Second, want: 0},
+		{name: "after pause 4", at: 10 * time.Second, want: 100 * time.Millisecond},
+	}
+	for _, step := range steps {
+		if got, _ := l.reserve(at(step.at)); got != step.want {
+			t.Errorf("%s: got delay %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestLimiterCancel(t *testing.T) {
+	l := newLimiter(10, 1)
+	now := l.last
+
+	l.reserve(now)
+	_, first := l.reserve(now)  // waits 100ms
+	_, second := l.reserve(now) // waits 200ms, counting on first's token
+
+	// second is the newest, so its token is given back
+	l.cancel(second)
+	if got, _ := l.reserve(now); got != 200*time.Millisecond {
+		t.Errorf("after cancelling the newest reservation: got delay %v, want 200ms", got)
+	}
+	// first has a caller queued behind it, so its token is kept
+	l.cancel(first)
+	if got, _ := l.reserve(now); got != 300*time.Millisecond {
+		t.Errorf("after cancelling a queued reservation: got delay %v, want 300ms", got)
+	}
+}
+
+func TestLimiterWaitCancelled(t *testing.T) {
+	l := newLimiter(1, 1)
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := l.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("wait returned after %v, want as soon as the context is done", elapsed)
+	}
+	// The token of the cancelled wait was given back
+	if got, _ := l.reserve(time.Now()); got > time.Second {
+		t.Errorf("after a cancelled wait: got delay %v, want at most 1s", got)
+	}
+
+	var none *limiter
+	if err := none.wait(ctx); err != nil {
+		t.Errorf("nil limiter: got %v, want nil", err)
+	}
+}
+
+func TestEndpointRateLimit(t *testing.T) {
+	c := New(
+		WithCredentials("id", "secret"),
+		WithBaseURL("http://127.0.0.1:0"),
+		WithRateLimit(0, 0),
+		WithEndpointRateLimit("/shopping/transfer-offers", 1, 1),
+	)
+	defer c.Close()
+
+	// waitAt returns the error of waiting for a request to path, within 10ms
+	waitAt := func(path string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		return c.waitForRateLimit(ctx, path)
+	}
+	if err := waitAt("/shopping/transfer-offers"); err != nil {
+		t.Errorf("first search: %v", err)
+	}
+	if err := waitAt("/shopping/transfer-offers?lang=fr"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second search within a second: got %v, want context.DeadlineExceeded", err)
+	}
+	for _, path := range []string{"/ordering/transfer-orders", "/security/oauth2/token", "/shopping"} {
+		if err := waitAt(path); err != nil {
+			t.Errorf("%s is not limited: got %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package amadeus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// do sends an authorized request to the Amadeus API and unmarshals the
+// response body into result. path is relative to the base URL and can
+// contain a query string. If the API responds with an error status, or
+// with an error payload, do returns an *APIError.
+// Transient failures are retried according to the client's retry
+// policy and the given mode (see retry.go).
+func (c *Client) do(ctx context.Context, method, path string, payload []byte, mode retryMode, result any) error {
+	return c.retry(ctx, method, path, mode, func() error {
+		token, err := c.token(ctx)
+		if err != nil {
+			return fmt.Errorf("c.token: %w", err)
+		}
+
+		err = c.send(ctx, token, method, path, payload, result)
+
+		// If the API rejects the token (for example, because it was
+		// revoked before it expired), get a new one and replay the
+		// request once. A rejected request was not processed, so
+		// this is safe even for bookings.
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized {
+			c.invalidateToken(ctx, token)
+			token, err = c.token(ctx)
+			if err != nil {
+				return fmt.Errorf("c.token: %w", err)
+			}
+			err = c.send(ctx, token, method, path, payload, result)
+		}
+		return err
+	})
+}
+
+// send makes a single attempt of the request described in do, using the given access token.
+func (c *Client) send(ctx context.Context, token, method, path string, payload []byte, result any) error {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+
+	if payload != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+	req.Header.Add("Authorization", "Bearer "+token)
+
+	err = c.waitForRateLimit(ctx, path)
+	if err != nil {
+		return fmt.Errorf("c.waitForRateLimit: %w", err)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("client.Do: %w", err)
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("io.ReadAll: %w", err)
+	}
+
+	if err := checkResponse(req, res, resBody); err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(resBody, result)
+	if err != nil {
+		return fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	return nil
+}
+
+// checkResponse returns an *APIError if the response has an error
+// status code or contains a non-empty "errors" array. Some Amadeus
+// APIs respond with status 200 even if the call failed, so the status
+// code alone is not sufficient.
+func checkResponse(req *http.Request, res *http.Response, body []byte) error {
+	var errResult errorResponse
+	// The body may not be JSON at all (for example, an error page of
+	// a proxy), so unmarshalling errors only matter for the success case.
+	jsonErr := json.Unmarshal(body, &errResult)
+
+	if res.StatusCode < 400 && (jsonErr != nil || len(errResult.Errors) == 0) {
+		return nil
+	}
+
+	apiErr := &APIError{
+		StatusCode: res.StatusCode,
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Errors:     errResult.Errors,
+		RetryAfter: parseRetryAfter(res.Header.Get("Retry-After")),
+	}
+	if res.StatusCode < 400 && len(errResult.Errors) > 0 && errResult.Errors[0].Status != 0 {
+		// The call "succeeded", but the payload knows better.
+		apiErr.StatusCode = errResult.Errors[0].Status
+	}
+	return apiErr
+}
@@ -0,0 +1,235 @@
+package amadeus
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how the client retries API calls that fail
+// with a transient error: a network error, or one of the status codes
+// 429, 500, 502, 503 and 504.
+//
+// Search and token requests do not change any state and are retried
+// on all transient errors. Booking and cancellation requests are only
+// retried if the request provably never reached the API: if the
+// connection could not be established, or if the API rejected the
+// request with 429 Too Many Requests.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the
+	// first one. A value of 1 or less disables retries.
+	MaxAttempts int
+	// InitialBackoff is the wait time before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait time between two attempts. A Retry-After
+	// header sent by the API replaces the backoff. If it asks for a
+	// longer wait than MaxBackoff, the client waits only if the context's
+	// deadline leaves time for it; otherwise it gives up and returns the
+	// error, whose *APIError has the requested wait in RetryAfter.
+	MaxBackoff time.Duration
+	// Multiplier grows the backoff after each attempt.
+	Multiplier float64
+	// Jitter is the fraction (0 to 1) of each backoff that is
+	// randomized, so that concurrent clients do not retry in lockstep.
+	Jitter float64
+	// OnRetry, if set, is called before each retry, for example to
+	// log the retry.
+	OnRetry func(RetryEvent)
+}
+
+// RetryEvent describes a failed attempt that is going to be retried.
+type RetryEvent struct {
+	Method  string
+	Path    string
+	Attempt int           // the attempt that failed, starting at 1
+	Wait    time.Duration // the wait time before the next attempt
+	Err     error         // the error of the failed attempt
+}
+
+// DefaultRetryPolicy returns the retry policy that New uses unless
+// WithRetryPolicy is passed: three attempts, starting with a backoff
+// of half a second.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.5,
+	}
+}
+
+// WithRetryPolicy sets the policy for retrying transient failures.
+// Pass RetryPolicy{} to disable retries.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = p
+	}
+}
+
+// retryMode tells retry which failures are safe to retry.
+type retryMode int
+
+const (
+	// retryTransient retries all transient failures.
+	// Use it for requests that do not change any state.
+	retryTransient retryMode = iota
+	// retryUnsent retries only failures where the request
+	// provably was not processed by the API.
+	retryUnsent
+)
+
+// retry calls attempt until it succeeds, returns a permanent error,
+// the policy's attempts are used up, or ctx is done. If more than one
+// attempt was made, the returned error says how many.
+func (c *Client) retry(ctx context.Context, method, path string, mode retryMode, attempt func() error) error {
+	p := c.retryPolicy
+	backoff := p.InitialBackoff
+
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+		if n >= p.MaxAttempts || !retryable(err, mode) || ctx.Err() != nil {
+			if n > 1 {
+				return fmt.Errorf("after %d attempts: %w", n, err)
+			}
+			return err
+		}
+
+		wait := p.jittered(backoff)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			// A wait longer than MaxBackoff is only worth it if the
+			// caller is prepared to wait that long
+			if p.MaxBackoff > 0 && apiErr.RetryAfter > p.MaxBackoff {
+				if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) <= apiErr.RetryAfter {
+					return fmt.Errorf("after %d attempts: %w (API asked to retry after %v)", n, err, apiErr.RetryAfter)
+				}
+			}
+			wait = apiErr.RetryAfter
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(RetryEvent{Method: method, Path: path, Attempt: n, Wait: wait, Err: err})
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("after %d attempts: %w (retry aborted: %w)", n, err, ctx.Err())
+		}
+
+		backoff = time.Duration(float64(backoff) * p.Multiplier)
+		if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+			backoff = p.MaxBackoff
+		}
+	}
+}
+
+// jittered randomly shortens the backoff by up to the policy's Jitter fraction.
+func (p RetryPolicy) jittered(backoff time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return backoff
+	}
+	return backoff - time.Duration(rand.Float64()*p.Jitter*float64(backoff))
+}
+
+// retryable reports whether err is a transient failure that is safe
+// to retry in the given mode.
+func retryable(err error, mode retryMode) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests:
+			// Rate-limited requests are rejected before they are processed.
+			return true
+		case http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return mode == retryTransient
+		}
+		return false
+	}
+
+	// The HTTP client wraps all its errors in a *url.Error, which is a
+	// net.Error itself, so classify the error it wraps instead.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+
+	// TLS and certificate errors do not go away by trying again.
+	var (
+		alertErr   tls.AlertError
+		headerErr  tls.RecordHeaderError
+		certErr    *tls.CertificateVerificationError
+		unknownCA  x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+		invalidErr x509.CertificateInvalidError
+	)
+	if errors.As(err, &alertErr) || errors.As(err, &headerErr) || errors.As(err, &certErr) ||
+		errors.As(err, &unknownCA) || errors.As(err, &hostErr) || errors.As(err, &invalidErr) {
+		return false
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		switch {
+		case opErr.Op == "dial":
+			// A failed dial means that the request never left this machine.
+			return true
+		case opErr.Op == "remote error":
+			// The server sent a TLS alert.
+			return false
+		}
+		// A failed read or write, e.g. a reset connection, may have
+		// happened after the API received the request.
+		return mode == retryTransient
+	}
+
+	// The same goes for timeouts and connections that were closed
+	// before the response was complete.
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return mode == retryTransient
+	}
+
+	// Anything else, such as a bad URL or a missing recording in
+	// offline mode, fails the same way every time.
+	return false
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is
+// either a number of seconds or an HTTP date. It returns 0 if the
+// header is missing or invalid.
+func parseRetryAfter(h string) time.Duration {
+	if h == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(h); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
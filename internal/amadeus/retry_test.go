@@ -0,0 +1,80 @@
+package amadeus
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// timeoutError is a net.Error that timed out, like the HTTP client's Client.Timeout error
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetryable(t *testing.T) {
+	// urlErr wraps err like the HTTP client does
+	urlErr := func(err error) error {
+		return &url.Error{Op: "Post", URL: "https://test.api.amadeus.com/v1/x", Err: err}
+	}
+	tests := []struct {
+		name              string
+		err               error
+		transient, unsent bool
+	}{
+		{"429", &APIError{StatusCode: 429}, true, true},
+		{"503", &APIError{StatusCode: 503}, true, false},
+		{"400", &APIError{StatusCode: 400}, false, false},
+		{"401", &APIError{StatusCode: 401}, false, false},
+		{"wrapped 503", fmt.Errorf("search: %w", &APIError{StatusCode: 503}), true, false},
+		{"connection refused", urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}), true, true},
+		{"connection reset", urlErr(&net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}), true, false},
+		{"connection closed", urlErr(io.EOF), true, false},
+		{"client timeout", urlErr(timeoutError{}), true, false},
+		{"cancelled", urlErr(context.Canceled), false, false},
+		{"deadline exceeded", urlErr(context.DeadlineExceeded), false, false},
+		{"bad URL", urlErr(errors.New("unsupported protocol scheme \"\"")), false, false},
+		{"no recording", urlErr(fmt.Errorf("%w: GET /v1/x", errors.New("cassette: no recording for request"))), false, false},
+		{"unknown CA", urlErr(&tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}), false, false},
+		{"wrong host", urlErr(x509.HostnameError{Host: "example.com", Certificate: &x509.Certificate{}}), false, false},
+		{"not TLS", urlErr(tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}), false, false},
+		{"TLS alert", urlErr(&net.OpError{Op: "remote error", Err: tls.AlertError(40)}), false, false},
+		{"other error", errors.New("boom"), false, false},
+	}
+	for _, tt := range tests {
+		if got := retryable(tt.err, retryTransient); got != tt.transient {
+			t.Errorf("%s: retryable transient = %v, want %v", tt.name, got, tt.transient)
+		}
+		if got := retryable(tt.err, retryUnsent); got != tt.unsent {
+			t.Errorf("%s: retryable unsent = %v, want %v", tt.name, got, tt.unsent)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"3", 3 * time.Second},
+		{"-1", 0},
+		{"soon", 0},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0}, // in the past
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.in); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
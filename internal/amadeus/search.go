@@ -1,77 +1,64 @@
 package amadeus
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"time"
 )
 
 // Search receives search parameters from the user and calls the
 // Search Tranfer API to receive a list of transfer offers.
 // It returns a SearchResponse struct containing the list of offers,
-// or an error if the search fails.
+// or an error if the parameters are invalid or the search fails.
 func (c *Client) Search(p SearchParameters) (SearchResponse, error) {
-	url := c.baseURL + "/shopping/transfer-offers"
-	method := "POST"
-
-	params, err := json.Marshal(p)
-	if err != nil {
-		return SearchResponse{}, fmt.Errorf("Search: json.Marshal: %w", err)
-	}
-
-	payload := bytes.NewReader(params)
-
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-	req, err := http.NewRequest(method, url, payload)
+	return c.SearchContext(context.Background(), p)
+}
 
-	if err != nil {
-		return SearchResponse{}, fmt.Errorf("Search: http.NewRequest: %w", err)
+// SearchContext is like Search but honours the cancellation and
+// deadline of ctx, both while waiting for an access token and
+// during the API call.
+func (c *Client) SearchContext(ctx context.Context, p SearchParameters) (SearchResponse, error) {
+	// Reject impossible searches without spending an API call on them.
+	// Invalid fields are returned as *FieldError (see params.go).
+	if err := p.Validate(); err != nil {
+		return SearchResponse{}, fmt.Errorf("Search: %w", err)
 	}
 
-	token, err := c.token()
+	params, err := json.Marshal(p)
 	if err != nil {
-		return SearchResponse{}, fmt.Errorf("Search: c.token: %w", err)
+		return SearchResponse{}, fmt.Errorf("Search: json.Marshal: %w", err)
 	}
 
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Authorization", "Bearer "+token)
-
-	res, err := client.Do(req)
+	// Send the request and unmarshal the response into a SearchResponse struct.
+	// API errors are returned as *APIError (see request.go).
+	result := SearchResponse{}
+	err = c.do(ctx, "POST", "/shopping/transfer-offers", params, retryTransient, &result)
 	if err != nil {
-		return SearchResponse{}, fmt.Errorf("Search: client.Do: %w", err)
+		return SearchResponse{}, fmt.Errorf("Search: %w", err)
 	}
-	defer res.Body.Close()
-
-	// Read the response body and unmarshal it into a SearchResponse variable
 
-	body, err := io.ReadAll(res.Body)
-	if err != nil {
-		return SearchResponse{}, fmt.Errorf("Search: io.ReadAll: %w", err)
-	}
+	// The API sends local times without a zone. They are in the
+	// zone of the airport at the start or end of the transfer.
+	loc, _ := AirportLocation(p.AirportCode())
+	result.localize(loc)
+	return result, nil
+}
 
-	// Check for API errors.
-	// HTTP status is 200 even if the booking fails,
-	// because technically, the call succeeded.
-	// Hence, we check for the occurrence of "errors" in the response body.
-	if bytes.Contains(body, []byte("errors")) {
-		errorResult := SearchErrorResponse{}
-		err = json.Unmarshal(body, &errorResult)
-		if err != nil {
-			return SearchResponse{}, fmt.Errorf("Search: json.Unmarshal: %w", err)
-		}
-		return SearchResponse{}, fmt.Errorf("Search failed: %s: %s (code %d)", errorResult.Errors[0].Title, errorResult.Errors[0].Detail, errorResult.Errors[0].Code)
+// AirportCode returns the IATA code of the airport at the end of the
+// transfer, or at its start if it ends at an address.
+func (p SearchParameters) AirportCode() string {
+	if p.EndLocationCode != "" {
+		return p.EndLocationCode
 	}
+	return p.StartLocationCode
+}
 
-	// Unmarshal the response into a SearchResponse struct
-	result := SearchResponse{}
-	err = json.Unmarshal(body, &result)
-	if err != nil {
-		return SearchResponse{}, fmt.Errorf("Search: json.Unmarshal: %w", err)
+// localize moves the date-times of all offers into loc.
+func (r *SearchResponse) localize(loc *time.Location) {
+	for i := range r.Data {
+		offer := &r.Data[i]
+		offer.Start.DateTime = offer.Start.DateTime.In(loc)
+		offer.End.DateTime = offer.End.DateTime.In(loc)
 	}
-	return result, nil
 }
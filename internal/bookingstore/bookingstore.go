@@ -0,0 +1,147 @@
+// Package bookingstore keeps the bookings made through the app, so that
+// travellers can look them up after the confirmation page is gone.
+//
+// Store is the storage interface; FileStore implements it with a single
+// JSON file:
+//
+//	store, err := bookingstore.NewFileStore("bookings.json")
+//	err = store.Save(bookingstore.New(owner, offerID, response, params, time.Now()))
+//
+// Every booking belongs to an owner, an opaque key of the traveller who
+// made it. Get, List and UpdateStatus only see the bookings of the owner
+// they are given; bookings of other owners are reported as not found.
+//
+// Payment card details are never stored.
+package bookingstore
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"airport-transfer-app/internal/amadeus"
+)
+
+// ErrNotFound is returned for orders or transfers that are not in the store,
+// or that belong to someone else.
+var ErrNotFound = errors.New("bookingstore: booking not found")
+
+// Store persists bookings. Implementations must be safe for concurrent use.
+type Store interface {
+	// Save adds a booking, or replaces the booking with the same order ID.
+	Save(b Booking) error
+	// Get returns the owner's booking with the given order ID, or ErrNotFound.
+	Get(owner, orderID string) (Booking, error)
+	// List returns the owner's bookings, newest first.
+	List(owner string) ([]Booking, error)
+	// GetByOffer returns the owner's newest booking of the offer with the
+	// given ID, or ErrNotFound. It lets the app recognize a booking form
+	// that is sent again, even after a restart.
+	GetByOffer(owner, offerID string) (Booking, error)
+	// UpdateStatus sets the status of one transfer of the owner's booking,
+	// for example to "CANCELLED". It returns ErrNotFound if the owner has
+	// no such order or transfer.
+	UpdateStatus(owner, orderID, confirmNbr, status string) error
+}
+
+// Booking is a stored transfer order: the order's identifiers, the
+// booked transfers with their offer details, price and status, and the
+// passengers. OfferID is the offer that was booked; with Owner, it is
+// the idempotency key of the booking form.
+type Booking struct {
+	Owner                 string                    `json:"owner"`
+	OfferID               string                    `json:"offerId,omitempty"`
+	OrderID               string                    `json:"orderId"`
+	Reference             string                    `json:"reference"`
+	CreatedAt             time.Time                 `json:"createdAt"`
+	Note                  string                    `json:"note,omitempty"`
+	Passengers            []amadeus.Passenger       `json:"passengers"`
+	Transfers             []amadeus.Transfer        `json:"transfers"`
+	StartConnectedSegment *amadeus.ConnectedSegment `json:"startConnectedSegment,omitempty"`
+	EndConnectedSegment   *amadeus.ConnectedSegment `json:"endConnectedSegment,omitempty"`
+}
+
+// New returns the owner's booking of an offer for a confirmed order. The
+// passengers and connected segments are taken from the booking request if
+// the response does not repeat them. The payment is left out.
+func New(owner, offerID string, response amadeus.BookingResponse, params amadeus.BookingParameters, createdAt time.Time) Booking {
+	b := Booking{
+		Owner:                 owner,
+		OfferID:               offerID,
+		OrderID:               response.Data.ID,
+		Reference:             response.Data.Reference,
+		CreatedAt:             createdAt,
+		Note:                  params.Data.Note,
+		Passengers:            response.Data.Passengers,
+		Transfers:             response.Data.Transfers,
+		StartConnectedSegment: params.Data.StartConnectedSegment,
+		EndConnectedSegment:   params.Data.EndConnectedSegment,
+	}
+	if len(b.Passengers) == 0 {
+		b.Passengers = params.Data.Passengers
+	}
+	return b
+}
+
+// Status returns the status of the booking: the status of its first
+// transfer that is not cancelled, or "CANCELLED" if all of them are.
+func (b Booking) Status() string {
+	for _, t := range b.Transfers {
+		if t.Status != "CANCELLED" {
+			return t.Status
+		}
+	}
+	return "CANCELLED"
+}
+
+// Price returns the total price of the booked transfers.
+func (b Booking) Price() (amadeus.Money, error) {
+	var prices []amadeus.Money
+	for _, t := range b.Transfers {
+		prices = append(prices, t.Quotation.MonetaryAmount)
+	}
+	return amadeus.Sum(prices...)
+}
+
+// Response returns the booking as the Transfer Booking API confirmed it,
+// for showing the confirmation page again.
+func (b Booking) Response() amadeus.BookingResponse {
+	return amadeus.BookingResponse{Data: amadeus.Order{
+		Type:       "transfer-order",
+		Reference:  b.Reference,
+		ID:         b.OrderID,
+		Passengers: b.Passengers,
+		Transfers:  b.Transfers,
+	}}
+}
+
+// Start returns the start of the first transfer, or nil if there is none.
+func (b Booking) Start() *amadeus.Location {
+	if len(b.Transfers) == 0 {
+		return nil
+	}
+	return &b.Transfers[0].Start
+}
+
+// End returns the end of the last transfer, or nil if there is none.
+func (b Booking) End() *amadeus.Location {
+	if len(b.Transfers) == 0 {
+		return nil
+	}
+	return &b.Transfers[len(b.Transfers)-1].End
+}
+
+// localize moves the date-times of the transfers into their airports'
+// zones, which JSON does not keep.
+func (b *Booking) localize() {
+	for i := range b.Transfers {
+		b.Transfers[i].Localize()
+	}
+}
+
+// sortNewestFirst sorts bookings by creation time, newest first.
+func sortNewestFirst(bookings []Booking) {
+	sort.SliceStable(bookings, func(i, j int) bool {
+		return bookings[i].CreatedAt.After(bookings[j].CreatedAt)
+	})
+}
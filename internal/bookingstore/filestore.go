@@ -0,0 +1,187 @@
+package bookingstore
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"airport-transfer-app/internal/amadeus"
+)
+
+// FileStore is a Store that keeps all bookings in one JSON file. It
+// holds the bookings in memory and rewrites the file on every change,
+// which is fine for the number of bookings a demo app makes. The file
+// is replaced atomically, so a crash never leaves it half-written.
+type FileStore struct {
+	path string
+
+	mu       sync.Mutex
+	bookings map[string]Booking
+}
+
+// NewFileStore opens the store in the file at path, which is created
+// with the first booking if it does not exist.
+func NewFileStore(path string) (*FileStore, error) {
+	s := &FileStore{path: path, bookings: map[string]Booking{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("bookingstore: %w", err)
+	}
+
+	var bookings []Booking
+	if err := json.Unmarshal(data, &bookings); err != nil {
+		return nil, fmt.Errorf("bookingstore: %s: %w", path, err)
+	}
+	for _, b := range bookings {
+		b.localize()
+		s.bookings[b.OrderID] = b
+	}
+	return s, nil
+}
+
+// Save adds or replaces a booking and writes the file.
+func (s *FileStore) Save(b Booking) error {
+	if b.OrderID == "" {
+		return errors.New("bookingstore: booking without order ID")
+	}
+	if b.Owner == "" {
+		return errors.New("bookingstore: booking without owner")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, existed := s.bookings[b.OrderID]
+	s.bookings[b.OrderID] = b
+	if err := s.write(); err != nil {
+		// Keep memory and file in sync.
+		if existed {
+			s.bookings[b.OrderID] = old
+		} else {
+			delete(s.bookings, b.OrderID)
+		}
+		return err
+	}
+	return nil
+}
+
+// Get returns the owner's booking by order ID.
+func (s *FileStore) Get(owner, orderID string) (Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.bookings[orderID]
+	if !ok || owner == "" || b.Owner != owner {
+		return Booking{}, ErrNotFound
+	}
+	return b, nil
+}
+
+// List returns the owner's bookings, newest first.
+func (s *FileStore) List(owner string) ([]Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var bookings []Booking
+	for _, b := range s.bookings {
+		if owner != "" && b.Owner == owner {
+			bookings = append(bookings, b)
+		}
+	}
+	sortNewestFirst(bookings)
+	return bookings, nil
+}
+
+// GetByOffer returns the owner's newest booking of an offer.
+func (s *FileStore) GetByOffer(owner, offerID string) (Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var newest Booking
+	found := false
+	for _, b := range s.bookings {
+		if owner == "" || offerID == "" || b.Owner != owner || b.OfferID != offerID {
+			continue
+		}
+		if !found || b.CreatedAt.After(newest.CreatedAt) {
+			newest, found = b, true
+		}
+	}
+	if !found {
+		return Booking{}, ErrNotFound
+	}
+	return newest, nil
+}
+
+// UpdateStatus sets the status of a transfer of the owner's booking and writes the file.
+func (s *FileStore) UpdateStatus(owner, orderID, confirmNbr, status string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.bookings[orderID]
+	if !ok || owner == "" || b.Owner != owner {
+		return ErrNotFound
+	}
+	// Copy the transfers, so that a failed write leaves the stored
+	// booking unchanged.
+	transfers := append([]amadeus.Transfer(nil), b.Transfers...)
+	found := false
+	for i := range transfers {
+		if transfers[i].ConfirmNbr == confirmNbr {
+			transfers[i].Status = status
+			found = true
+		}
+	}
+	if !found {
+		return ErrNotFound
+	}
+
+	old := b
+	b.Transfers = transfers
+	s.bookings[orderID] = b
+	if err := s.write(); err != nil {
+		s.bookings[orderID] = old
+		return err
+	}
+	return nil
+}
+
+// write replaces the file with the current bookings. s.mu must be held.
+func (s *FileStore) write() error {
+	bookings := make([]Booking, 0, len(s.bookings))
+	for _, b := range s.bookings {
+		bookings = append(bookings, b)
+	}
+	sortNewestFirst(bookings)
+
+	data, err := json.MarshalIndent(bookings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("bookingstore: json.MarshalIndent: %w", err)
+	}
+
+	// Write to a temporary file next to the store and rename it, which
+	// replaces the store in one step.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("bookingstore: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("bookingstore: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("bookingstore: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("bookingstore: %w", err)
+	}
+	return nil
+}
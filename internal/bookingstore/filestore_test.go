@@ -0,0 +1,105 @@
+package bookingstore
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"airport-transfer-app/internal/amadeus"
+)
+
+// booking returns a booking of one transfer with confirmation number 1, of the offer "OFFER-" + orderID
+func booking(owner, orderID string, createdAt time.Time) Booking {
+	return Booking{
+		Owner:     owner,
+		OfferID:   "OFFER-" + orderID,
+		OrderID:   orderID,
+		CreatedAt: createdAt,
+		Transfers: []amadeus.Transfer{{ConfirmNbr: "1", Status: "CONFIRMED"}},
+	}
+}
+
+func TestFileStoreOwners(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bookings.json")
+	s, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for _, b := range []Booking{
+		booking("alice", "ORDER-1", now.Add(-time.Hour)),
+		booking("bob", "ORDER-2", now.Add(-time.Minute)),
+		booking("alice", "ORDER-3", now),
+	} {
+		if err := s.Save(b); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Save(booking("", "ORDER-4", now)); err == nil {
+		t.Error("saved a booking without owner")
+	}
+
+	// The file is read back the same way
+	reopened, err := NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, store := range []Store{s, reopened} {
+		list, err := store.List("alice")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 2 || list[0].OrderID != "ORDER-3" || list[1].OrderID != "ORDER-1" {
+			t.Errorf("alice's bookings: got %v, want ORDER-3 and ORDER-1", orderIDs(list))
+		}
+		if list, _ := store.List(""); len(list) != 0 {
+			t.Errorf("bookings without owner: got %v, want none", orderIDs(list))
+		}
+
+		if _, err := store.Get("alice", "ORDER-1"); err != nil {
+			t.Errorf("alice's ORDER-1: %v", err)
+		}
+		if _, err := store.Get("bob", "ORDER-1"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("ORDER-1 for bob: got %v, want ErrNotFound", err)
+		}
+		if _, err := store.Get("alice", "ORDER-9"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("unknown order: got %v, want ErrNotFound", err)
+		}
+
+		if b, err := store.GetByOffer("alice", "OFFER-ORDER-3"); err != nil || b.OrderID != "ORDER-3" {
+			t.Errorf("alice's booking of OFFER-ORDER-3: got %s, %v, want ORDER-3", b.OrderID, err)
+		}
+		if _, err := store.GetByOffer("bob", "OFFER-ORDER-3"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("bob's booking of OFFER-ORDER-3: got %v, want ErrNotFound", err)
+		}
+		if _, err := store.GetByOffer("alice", ""); !errors.Is(err, ErrNotFound) {
+			t.Errorf("booking without offer ID: got %v, want ErrNotFound", err)
+		}
+	}
+
+	if err := s.UpdateStatus("bob", "ORDER-1", "1", "CANCELLED"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("bob cancelling ORDER-1: got %v, want ErrNotFound", err)
+	}
+	if err := s.UpdateStatus("alice", "ORDER-1", "2", "CANCELLED"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown transfer: got %v, want ErrNotFound", err)
+	}
+	if err := s.UpdateStatus("alice", "ORDER-1", "1", "CANCELLED"); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err = NewFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, _ := reopened.Get("alice", "ORDER-1"); b.Status() != "CANCELLED" {
+		t.Errorf("status after cancellation: got %q, want CANCELLED", b.Status())
+	}
+}
+
+func orderIDs(bookings []Booking) []string {
+	var ids []string
+	for _, b := range bookings {
+		ids = append(ids, b.OrderID)
+	}
+	return ids
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	"airport-transfer-app/internal/amadeus"
+)
+
+// requestLocale returns the preferred language of the browser, as sent
+// in the Accept-Language header (e.g. "fr-FR"), or "en" if there is none.
+func requestLocale(r *http.Request) string {
+	first, _, _ := strings.Cut(r.Header.Get("Accept-Language"), ",")
+	first, _, _ = strings.Cut(first, ";")
+	first = strings.TrimSpace(first)
+	if first == "" || first == "*" {
+		return "en"
+	}
+	return first
+}
+
+// templateFuncs returns the helper functions of our templates,
+// formatting values for the given locale.
+func templateFuncs(locale string) template.FuncMap {
+	return template.FuncMap{
+		"money": func(m amadeus.Money) string {
+			return m.Format(locale)
+		},
+		// cancellationFee formats the fee of a cancellation rule for a transfer
+		// with the given price, or returns "" if the fee cannot be computed, so
+		// that the template shows a fallback instead of failing halfway
+		"cancellationFee": func(r amadeus.CancellationRule, price amadeus.Money) string {
+			fee, err := r.Fee(price)
+			if err != nil {
+				return ""
+			}
+			return fee.Format(locale)
+		},
+		"datetime": func(d amadeus.LocalDateTime) string {
+			if d.IsZero() {
+				return ""
+			}
+			return d.Time().Format("Mon 2 Jan 2006, 15:04 MST")
+		},
+		"duration":           formatDuration,
+		"transferType":       transferType,
+		"vehicleCategory":    vehicleCategory,
+		"paymentMethod":      paymentMethod,
+		"cancellationWindow": cancellationWindow,
+	}
+}
+
+// formatDuration formats a duration in days, hours and minutes, e.g. "1 d 2 h 5 min".
+// Negative durations are formatted as "past".
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		return "past"
+	}
+	d = d.Round(time.Minute)
+	days := d / (24 * time.Hour)
+	hours := (d % (24 * time.Hour)) / time.Hour
+	minutes := (d % time.Hour) / time.Minute
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%d d", days))
+	}
+	if hours > 0 {
+		parts = append(parts, fmt.Sprintf("%d h", hours))
+	}
+	if minutes > 0 || len(parts) == 0 {
+		parts = append(parts, fmt.Sprintf("%d min", minutes))
+	}
+	return strings.Join(parts, " ")
+}
@@ -2,28 +2,119 @@ package main
 
 import (
 	"fmt"
+	"log"
 	"os"
 	"os/signal"
 
+	// Embed the time zone database, so that airport times are
+	// correct even on systems without one
+	_ "time/tzdata"
+
 	"airport-transfer-app/internal/amadeus"
+	"airport-transfer-app/internal/amadeus/cassette"
+	"airport-transfer-app/internal/bookingstore"
 )
 
 type app struct {
 	amadeusClient *amadeus.Client
+	sessions      *sessionStore
+	bookings      bookingstore.Store
 }
 
 func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run starts the application and serves it until the interrupt signal.
+// Errors are returned rather than fatal, so that deferred clean-ups,
+// such as stopping the offline stand-in, run before the program exits.
+func run() error {
 	// Create a channel to handle the interrupt signal
 	interrupt := make(chan os.Signal, 1)
 	signal.Notify(interrupt, os.Interrupt)
 
+	// Use the test environment unless production is requested explicitly
+	env := amadeus.Test
+	if os.Getenv("AMADEUS_ENVIRONMENT") == "production" {
+		env = amadeus.Production
+	}
+
+	// Read the credentials from files (e.g. Docker secrets) if requested,
+	// otherwise from AMADEUS_CLIENT_ID and AMADEUS_CLIENT_SECRET
+	var credentials amadeus.CredentialsProvider = amadeus.EnvCredentials{}
+	if idFile, secretFile := os.Getenv("AMADEUS_CLIENT_ID_FILE"), os.Getenv("AMADEUS_CLIENT_SECRET_FILE"); idFile != "" && secretFile != "" {
+		credentials = amadeus.FileCredentials{IDFile: idFile, SecretFile: secretFile}
+	}
+
+	// Log every retry of a failed Amadeus API call
+	retryPolicy := amadeus.DefaultRetryPolicy()
+	retryPolicy.OnRetry = func(e amadeus.RetryEvent) {
+		log.Printf("amadeus: %s %s failed (attempt %d), retrying in %v: %v", e.Method, e.Path, e.Attempt, e.Wait, e.Err)
+	}
+
+	opts := []amadeus.Option{
+		amadeus.WithEnvironment(env),
+		amadeus.WithCredentialsProvider(credentials),
+		amadeus.WithRetryPolicy(retryPolicy),
+	}
+
+	// In offline mode, talk to a local stand-in instead of Amadeus
+	// (see offline.go, which is only built with -tags offline)
+	if os.Getenv("AMADEUS_OFFLINE") != "" {
+		offlineOpts, stop, err := startOffline()
+		if err != nil {
+			return err
+		}
+		defer stop()
+		opts = append(opts, offlineOpts...)
+	}
+
+	// Record Amadeus traffic to disk, or replay it from there
+	// (see internal/amadeus/cassette)
+	if dir := os.Getenv("AMADEUS_RECORD_DIR"); dir != "" {
+		log.Println("Recording Amadeus traffic to", dir)
+		opts = append(opts, amadeus.WithTransport(cassette.NewRecorder(dir, nil)))
+	} else if dir := os.Getenv("AMADEUS_REPLAY_DIR"); dir != "" {
+		replayer, err := cassette.NewReplayer(dir)
+		if err != nil {
+			return err
+		}
+		log.Println("Replaying Amadeus traffic from", dir)
+		// The recordings contain no credentials, so any will do
+		opts = append(opts,
+			amadeus.WithTransport(replayer),
+			amadeus.WithCredentials("replay", "replay"),
+		)
+	}
+
+	// Keep the bookings in a JSON file, bookings.json unless BOOKINGS_FILE says otherwise
+	// (see internal/bookingstore)
+	bookingsFile := os.Getenv("BOOKINGS_FILE")
+	if bookingsFile == "" {
+		bookingsFile = "bookings.json"
+	}
+	bookings, err := bookingstore.NewFileStore(bookingsFile)
+	if err != nil {
+		return err
+	}
+
 	// Start the application
 	app := &app{
-		amadeusClient: amadeus.New(),
+		amadeusClient: amadeus.New(opts...),
+		sessions:      newSessionStore(),
+		bookings:      bookings,
 	}
-	startServer(app)
+	defer app.amadeusClient.Close()
+	serverErr := startServer(app)
 
-	// Wait for the interrupt signal before exiting
-	<-interrupt
-	fmt.Println("Exiting...")
+	// Wait for the interrupt signal before exiting, unless the server fails
+	select {
+	case <-interrupt:
+		fmt.Println("Exiting...")
+		return nil
+	case err := <-serverErr:
+		return err
+	}
 }
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"airport-transfer-app/internal/amadeus"
+)
+
+// transferTypes are the names of the transfer types of the Transfer APIs
+var transferTypes = map[amadeus.TransferType]string{
+	amadeus.TransferPrivate:        "Private transfer",
+	amadeus.TransferShared:         "Shared transfer",
+	amadeus.TransferTaxi:           "Taxi",
+	amadeus.TransferHourly:         "Hourly service",
+	amadeus.TransferAirportExpress: "Airport express",
+	amadeus.TransferAirportBus:     "Airport bus",
+}
+
+// vehicleCategories are the names of the vehicle categories of the Transfer APIs
+var vehicleCategories = map[amadeus.VehicleCategory]string{
+	amadeus.VehicleStandard:   "Standard",
+	amadeus.VehicleBusiness:   "Business",
+	amadeus.VehicleFirstClass: "First class",
+}
+
+// paymentMethods are the names of the payment methods of the Transfer APIs
+var paymentMethods = map[amadeus.PaymentMethod]string{
+	amadeus.PaymentCreditCard:             "Credit card",
+	amadeus.PaymentInvoice:                "Invoice",
+	amadeus.PaymentTravelAccount:          "Travel account",
+	amadeus.PaymentPaymentServiceProvider: "Payment service provider",
+}
+
+// transferType returns the name of a transfer type, or its code if we do not know it
+func transferType(t amadeus.TransferType) string {
+	if name, ok := transferTypes[t]; ok {
+		return name
+	}
+	return string(t)
+}
+
+// vehicleCategory returns the name of a vehicle category, or its code if we do not know it
+func vehicleCategory(c amadeus.VehicleCategory) string {
+	if name, ok := vehicleCategories[c]; ok {
+		return name
+	}
+	return string(c)
+}
+
+// paymentMethod returns the name of a payment method, or its code if we do not know it
+func paymentMethod(m amadeus.PaymentMethod) string {
+	if name, ok := paymentMethods[m]; ok {
+		return name
+	}
+	return string(m)
+}
+
+// cancellationWindow describes the time window of a cancellation rule,
+// e.g. "24 hours or more before pickup". The APIs send 9999 for "no limit".
+func cancellationWindow(r amadeus.CancellationRule) string {
+	unit := strings.ToLower(string(r.MetricType))
+	min, errMin := strconv.Atoi(r.MetricMin)
+	max, errMax := strconv.Atoi(r.MetricMax)
+	switch {
+	case errMin != nil && errMax != nil:
+		return ""
+	case (errMin != nil || min <= 0) && (errMax != nil || max >= 9999):
+		return "any time before pickup"
+	case errMax != nil || max >= 9999:
+		return fmt.Sprintf("%d %s or more before pickup", min, unit)
+	case errMin != nil || min <= 0:
+		return fmt.Sprintf("less than %d %s before pickup", max, unit)
+	default:
+		return fmt.Sprintf("%d to %d %s before pickup", min, max, unit)
+	}
+}
+
+// baggageCount returns the number of pieces of baggage a vehicle takes, or 0 if the offer does not tell
+func baggageCount(v amadeus.Vehicle) int {
+	n := 0
+	for _, b := range v.Baggages {
+		n += b.Count
+	}
+	return n
+}
+
+// cancellationRuleApplies reports whether a cancellation rule covers a cancellation the given time
+// before pickup. Like cancellationWindow, it reads the window as including MetricMin and excluding
+// MetricMax, where a MetricMax that is missing or 9999 means no limit.
+func cancellationRuleApplies(r amadeus.CancellationRule, before time.Duration) bool {
+	var unit time.Duration
+	switch r.MetricType {
+	case amadeus.MetricMinutes:
+		unit = time.Minute
+	case amadeus.MetricHours:
+		unit = time.Hour
+	case amadeus.MetricDays:
+		unit = 24 * time.Hour
+	default:
+		return false
+	}
+	min, err := strconv.Atoi(r.MetricMin)
+	if err != nil {
+		min = 0
+	}
+	if before < time.Duration(min)*unit {
+		return false
+	}
+	max, err := strconv.Atoi(r.MetricMax)
+	return err != nil || max >= 9999 || before < time.Duration(max)*unit
+}
+
+// offerCancellationFee returns the fee for cancelling an offer at the given time, from the first
+// cancellation rule that applies. It returns false if no rule applies, so the fee is not known,
+// or if the fee cannot be computed.
+func offerCancellationFee(o amadeus.Offer, at time.Time) (amadeus.Money, bool) {
+	before := o.Start.DateTime.Time().Sub(at)
+	for _, r := range o.CancellationRules {
+		if cancellationRuleApplies(r, before) {
+			fee, err := r.Fee(o.Quotation.MonetaryAmount)
+			return fee, err == nil
+		}
+	}
+	return amadeus.Money{}, false
+}
+
+// offerDetailsTemplate renders the parts that offers and booked transfers have in common.
+// Each takes an amadeus.Offer or amadeus.Transfer:
+//
+//	{{template "vehicle" .}}            description, category, seats and baggage
+//	{{template "provider" .}}           name and logo of the service provider, with a link to its terms
+//	{{template "price" .}}              the price breakdown: base, discount, taxes, fees and total
+//	{{template "cancellationPolicy" .}} the fee of each cancellation rule
+//
+// It needs the "money", "vehicleCategory", "cancellationWindow" and "cancellationFee" functions of templateFuncs.
+const offerDetailsTemplate = `{{define "vehicle"}}{{with .Vehicle}}
+	{{.Description}}{{with .Category}} ({{vehicleCategory .}}){{end}}
+	{{with .SeatCount}}<br/>{{.}} seats{{end}}
+	{{range .Baggages}}<br/>{{.Count}} bag(s){{with .Size}}, size {{.}}{{end}}{{end}}
+{{end}}{{end}}
+
+{{define "provider"}}{{with .ServiceProvider}}
+	{{with .LogoURL}}<img class="provider-logo" src="{{.}}" alt=""> {{end}}{{.Name}}
+	{{with .TermsURL}}<br/><a href="{{.}}" target="_blank" rel="noopener">Terms and conditions</a> <span class="print-only">({{.}})</span>{{end}}
+{{end}}{{end}}
+
+{{define "price"}}
+	<table class="price">
+		{{with .Quotation}}
+		{{if not .Base.MonetaryAmount.IsZero}}<tr><td>Base price</td><td>{{money .Base.MonetaryAmount}}</td></tr>{{end}}
+		{{if not .Discount.MonetaryAmount.IsZero}}<tr><td>Discount</td><td>{{money .Discount.MonetaryAmount.Neg}}</td></tr>{{end}}
+		{{if gt (len .Taxes) 1}}{{range .Taxes}}<tr><td>Tax</td><td>{{money .MonetaryAmount}}</td></tr>{{end}}{{end}}
+		{{if not .TotalTaxes.MonetaryAmount.IsZero}}<tr><td>Total taxes</td><td>{{money .TotalTaxes.MonetaryAmount}}</td></tr>{{end}}
+		{{if not .TotalFees.MonetaryAmount.IsZero}}<tr><td>Fees</td><td>{{money .TotalFees.MonetaryAmount}}</td></tr>{{end}}
+		<tr><td>Total</td><td><strong>{{money .MonetaryAmount}}</strong>{{if .IsEstimated}} (estimated){{end}}</td></tr>
+		{{end}}
+		{{if .Converted.CurrencyCode}}<tr><td>Total in {{.Converted.CurrencyCode}}</td><td>{{money .Converted.MonetaryAmount}}</td></tr>{{end}}
+	</table>
+{{end}}
+
+{{define "cancellationPolicy"}}
+	{{$price := .Quotation.MonetaryAmount}}
+	{{if .CancellationRules}}
+	<table class="cancellation">
+		{{range .CancellationRules}}
+		{{$fee := or (cancellationFee . $price) "unknown, see the terms and conditions"}}
+		<tr><td>{{cancellationWindow .}}</td><td>{{if .RuleDescription}}{{.RuleDescription}} (fee {{$fee}}){{else}}Fee {{$fee}}{{end}}</td></tr>
+		{{end}}
+	</table>
+	{{else}}
+	<p>The service provider has not sent a cancellation policy. Please see their terms and conditions.</p>
+	{{end}}
+{{end}}`
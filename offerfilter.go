@@ -0,0 +1,229 @@
+package main
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"airport-transfer-app/internal/amadeus"
+)
+
+// offerFilterParams are the query parameters of /search that sort and filter the offers
+// rather than describe the transfer
+var offerFilterParams = []string{"sort", "transferType", "minSeats", "minBags", "paymentMethod", "freeCancellation", "maxPrice"}
+
+// offerSorts are the orders that the offer list can be sorted in, by the value of the "sort" parameter
+var offerSorts = []struct{ Value, Label string }{
+	{"", "Best match"},
+	{"price", "Price"},
+	{"pickup", "Pickup time"},
+	{"arrival", "Arrival time"},
+	{"provider", "Service provider"},
+	{"category", "Vehicle category"},
+}
+
+// offerTransferTypes and offerPaymentMethods are the choices of the filter form
+var (
+	offerTransferTypes = []amadeus.TransferType{
+		amadeus.TransferPrivate, amadeus.TransferShared, amadeus.TransferTaxi,
+		amadeus.TransferHourly, amadeus.TransferAirportExpress, amadeus.TransferAirportBus,
+	}
+	offerPaymentMethods = []amadeus.PaymentMethod{
+		amadeus.PaymentCreditCard, amadeus.PaymentInvoice,
+		amadeus.PaymentTravelAccount, amadeus.PaymentPaymentServiceProvider,
+	}
+)
+
+// vehicleCategoryRank orders the vehicle categories from standard to first class
+var vehicleCategoryRank = map[amadeus.VehicleCategory]int{
+	amadeus.VehicleStandard:   1,
+	amadeus.VehicleBusiness:   2,
+	amadeus.VehicleFirstClass: 3,
+}
+
+// offerFilter holds how the user wants the offers sorted and filtered.
+// Zero values mean "don't care".
+type offerFilter struct {
+	Sort             string
+	TransferTypes    []amadeus.TransferType
+	MinSeats         int
+	MinBags          int
+	PaymentMethod    amadeus.PaymentMethod
+	FreeCancellation bool
+	MaxPrice         string
+	// Currency is the currency of MaxPrice and of the price order
+	// (see priceCurrency). It is not a query parameter.
+	Currency string
+}
+
+// parseOfferFilter reads the sort order and filters from the query parameters.
+// Values that make no sense are ignored, so that a mangled link still shows offers.
+func parseOfferFilter(q url.Values) offerFilter {
+	f := offerFilter{
+		PaymentMethod:    amadeus.PaymentMethod(strings.ToUpper(q.Get("paymentMethod"))),
+		FreeCancellation: q.Get("freeCancellation") != "",
+	}
+	for _, s := range offerSorts {
+		if q.Get("sort") == s.Value {
+			f.Sort = s.Value
+		}
+	}
+	for _, t := range q["transferType"] {
+		if t := amadeus.TransferType(strings.ToUpper(t)); t.Known() {
+			f.TransferTypes = append(f.TransferTypes, t)
+		}
+	}
+	if !f.PaymentMethod.Known() {
+		f.PaymentMethod = ""
+	}
+	if n, err := strconv.Atoi(q.Get("minSeats")); err == nil && n > 0 {
+		f.MinSeats = n
+	}
+	if n, err := strconv.Atoi(q.Get("minBags")); err == nil && n > 0 {
+		f.MinBags = n
+	}
+	if d, err := amadeus.ParseDecimal(q.Get("maxPrice")); err == nil && d.Sign() > 0 {
+		f.MaxPrice = d.String()
+	}
+	return f
+}
+
+// IsSet reports whether the filter leaves any offers out
+func (f offerFilter) IsSet() bool {
+	return len(f.TransferTypes) > 0 || f.MinSeats > 0 || f.MinBags > 0 ||
+		f.PaymentMethod != "" || f.FreeCancellation || f.MaxPrice != ""
+}
+
+// HasTransferType reports whether the filter asks for offers of the given type
+func (f offerFilter) HasTransferType(t amadeus.TransferType) bool {
+	for _, want := range f.TransferTypes {
+		if want == t {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether an offer passes the filter. Offers that do not tell
+// their seats, baggage or cancellation fee do not pass a filter on them.
+func (f offerFilter) matches(o amadeus.Offer, now time.Time) bool {
+	if len(f.TransferTypes) > 0 && !f.HasTransferType(o.TransferType) {
+		return false
+	}
+	if f.MinSeats > 0 && o.Vehicle.SeatCount() < f.MinSeats {
+		return false
+	}
+	if f.MinBags > 0 && baggageCount(o.Vehicle) < f.MinBags {
+		return false
+	}
+	if f.PaymentMethod != "" && !acceptsPayment(o, f.PaymentMethod) {
+		return false
+	}
+	if f.FreeCancellation {
+		if fee, ok := offerCancellationFee(o, now); !ok || !fee.IsZero() {
+			return false
+		}
+	}
+	if f.MaxPrice != "" {
+		// Prices in another currency cannot be compared, so they do not pass
+		max := amadeus.Money{Amount: amadeus.MustParseDecimal(f.MaxPrice), Currency: f.Currency}
+		if c, err := offerPrice(o).Cmp(max); err != nil || c > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// apply returns the offers that pass the filter in the requested order, and the number of offers left out.
+// Offers that compare equal keep the order of the API.
+func (f offerFilter) apply(offers []amadeus.Offer, now time.Time) ([]amadeus.Offer, int) {
+	var matching []amadeus.Offer
+	for _, o := range offers {
+		if f.matches(o, now) {
+			matching = append(matching, o)
+		}
+	}
+
+	var less func(a, b amadeus.Offer) bool
+	switch f.Sort {
+	case "price":
+		// Offers in another currency go last, grouped by currency
+		less = func(a, b amadeus.Offer) bool {
+			pa, pb := offerPrice(a), offerPrice(b)
+			if pa.Currency != pb.Currency {
+				if pa.Currency == f.Currency || pb.Currency == f.Currency {
+					return pa.Currency == f.Currency
+				}
+				return pa.Currency < pb.Currency
+			}
+			c, err := pa.Cmp(pb)
+			return err == nil && c < 0
+		}
+	case "pickup":
+		less = func(a, b amadeus.Offer) bool { return a.Start.DateTime.Before(b.Start.DateTime) }
+	case "arrival":
+		less = func(a, b amadeus.Offer) bool { return a.End.DateTime.Before(b.End.DateTime) }
+	case "provider":
+		less = func(a, b amadeus.Offer) bool {
+			return strings.ToLower(a.ServiceProvider.Name) < strings.ToLower(b.ServiceProvider.Name)
+		}
+	case "category":
+		// Unknown categories go last
+		rank := func(o amadeus.Offer) int {
+			if r, ok := vehicleCategoryRank[o.Vehicle.Category]; ok {
+				return r
+			}
+			return len(vehicleCategoryRank) + 1
+		}
+		less = func(a, b amadeus.Offer) bool { return rank(a) < rank(b) }
+	}
+	if less != nil {
+		sort.SliceStable(matching, func(i, j int) bool { return less(matching[i], matching[j]) })
+	}
+	return matching, len(offers) - len(matching)
+}
+
+// offerPrice returns the total price of an offer, converted to the requested
+// currency if the API has done so
+func offerPrice(o amadeus.Offer) amadeus.Money {
+	if o.Converted.CurrencyCode != "" {
+		return o.Converted.MonetaryAmount
+	}
+	return o.Quotation.MonetaryAmount
+}
+
+// priceCurrency returns the currency in which prices are compared: that of
+// the first offer of the API, as the offers are usually all in one currency
+func priceCurrency(offers []amadeus.Offer) string {
+	for _, o := range offers {
+		if currency := offerPrice(o).Currency; currency != "" {
+			return currency
+		}
+	}
+	return ""
+}
+
+// acceptsPayment reports whether an offer can be paid with the given method
+func acceptsPayment(o amadeus.Offer, m amadeus.PaymentMethod) bool {
+	for _, accepted := range o.MethodsOfPaymentAccepted {
+		if accepted == m {
+			return true
+		}
+	}
+	return false
+}
+
+// withoutOfferFilter returns the query parameters of a search without those of the filter,
+// so that the filter form can send the search again with new filters
+func withoutOfferFilter(q url.Values) url.Values {
+	search := url.Values{}
+	for key, values := range q {
+		search[key] = values
+	}
+	for _, key := range offerFilterParams {
+		search.Del(key)
+	}
+	return search
+}
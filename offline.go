@@ -0,0 +1,24 @@
+//go:build offline
+
+package main
+
+import (
+	"log"
+
+	"airport-transfer-app/internal/amadeus"
+	"airport-transfer-app/internal/amadeus/amadeustest"
+)
+
+// startOffline starts a local stand-in of the Amadeus APIs (see internal/amadeus/amadeustest)
+// and returns the client options that point to it, and a function that stops it.
+// It is only built with the offline build tag, so that production binaries do not
+// contain the stand-in and the test packages it uses.
+func startOffline() ([]amadeus.Option, func(), error) {
+	standIn := amadeustest.NewServer()
+	log.Println("Offline mode: using the Amadeus stand-in at", standIn.URL())
+	opts := []amadeus.Option{
+		amadeus.WithBaseURL(standIn.URL()),
+		amadeus.WithCredentials(amadeustest.ClientID, amadeustest.ClientSecret),
+	}
+	return opts, standIn.Close, nil
+}
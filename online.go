@@ -0,0 +1,14 @@
+//go:build !offline
+
+package main
+
+import (
+	"errors"
+
+	"airport-transfer-app/internal/amadeus"
+)
+
+// startOffline fails in binaries built without the offline build tag (see offline.go)
+func startOffline() ([]amadeus.Option, func(), error) {
+	return nil, nil, errors.New("AMADEUS_OFFLINE is set, but this binary was built without offline mode (use go run -tags offline .)")
+}
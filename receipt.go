@@ -0,0 +1,67 @@
+package main
+
+// receiptStyle lays out the receipt pages. When printing, it leaves the buttons and forms out
+// and spells out the links to the terms and conditions.
+// Include it in the head of the page with {{template "receiptStyle"}}.
+const receiptStyle = `{{define "receiptStyle"}}
+	<style>
+		body { font-family: sans-serif; max-width: 50em; }
+		table.receipt, table.price, table.cancellation { border-collapse: collapse; margin-bottom: 1em; }
+		table.receipt td, table.price td, table.cancellation td { padding: 0.2em 1em 0.2em 0; vertical-align: top; }
+		table.receipt td:first-child { font-weight: bold; }
+		.provider-logo { max-height: 2em; vertical-align: middle; }
+		.print-only { display: none; }
+		@media print {
+			form, button, .no-print { display: none; }
+			.print-only { display: inline; }
+			a { color: inherit; text-decoration: none; }
+		}
+	</style>
+{{end}}`
+
+// receiptTemplate renders the parts of a booking receipt:
+//
+//	{{template "place" .}}      a Location in short, as airport code or street and city
+//	{{template "address" .}}    a Location in full, with its name and postal address
+//	{{template "transfer" .}}   an amadeus.Transfer with route, vehicle, provider, price and cancellation rules
+//	{{template "passengers" .}} a list of amadeus.Passenger with contacts and billing address
+//
+// It needs offerDetailsTemplate, and the "money", "datetime", "transferType", "paymentMethod",
+// "vehicleCategory" and "cancellationWindow" functions of templateFuncs.
+const receiptTemplate = `{{define "place"}}{{if .LocationCode}}{{.LocationCode}}{{else}}{{.Address.Line}}, {{.Address.CityName}}{{end}}{{end}}
+
+{{define "address"}}{{with .Name}}{{.}}<br/>{{end}}{{if .LocationCode}}Airport {{.LocationCode}}{{else}}{{.Address.Line}}<br/>{{.Address.Zip}} {{.Address.CityName}}, {{.Address.CountryCode}}{{end}}{{end}}
+
+{{define "transfer"}}
+	<h2>Transfer {{.ConfirmNbr}}</h2>
+	<table class="receipt">
+		<tr><td>Status</td><td>{{.Status}}</td></tr>
+		<tr><td>Confirmation number</td><td>{{.ConfirmNbr}}</td></tr>
+		<tr><td>Transfer type</td><td>{{transferType .TransferType}}</td></tr>
+		<tr><td>Pickup</td><td>{{datetime .Start.DateTime}}<br/>{{template "address" .Start}}</td></tr>
+		<tr><td>Drop-off</td><td>{{datetime .End.DateTime}}<br/>{{template "address" .End}}</td></tr>
+		<tr><td>Vehicle</td><td>{{template "vehicle" .}}</td></tr>
+		<tr><td>Service provider</td><td>{{template "provider" .}}</td></tr>
+		{{with .MethodOfPayment}}<tr><td>Payment</td><td>{{paymentMethod .}}</td></tr>{{end}}
+		{{with .Note}}<tr><td>Note to driver</td><td>{{.}}</td></tr>{{end}}
+	</table>
+
+	<h3>Price</h3>
+	{{template "price" .}}
+
+	<h3>Cancellation policy</h3>
+	{{template "cancellationPolicy" .}}
+{{end}}
+
+{{define "passengers"}}
+	<h2>Passengers</h2>
+	<table class="receipt">
+		{{range .}}
+		<tr>
+			<td>{{.Title}} {{.FirstName}} {{.LastName}}</td>
+			<td>{{.Contacts.Email}}<br/>{{.Contacts.PhoneNumber}}
+				{{with .BillingAddress}}{{if .Line}}<br/>Billing address: {{.Line}}, {{.Zip}} {{.CityName}}, {{.CountryCode}}{{end}}{{end}}</td>
+		</tr>
+		{{end}}
+	</table>
+{{end}}`
@@ -5,7 +5,8 @@ import (
 	"net/http"
 )
 
-func startServer(a *app) {
+// routes returns the handler for all pages of the app
+func (a *app) routes() http.Handler {
 	mux := http.NewServeMux()
 
 	// Route for the search form page
@@ -14,12 +15,29 @@ func startServer(a *app) {
 	// Route for submitting the search
 	mux.HandleFunc("/search", a.SearchHandler)
 
+	// Route for the passenger details form
+	mux.HandleFunc("/booking/details", a.BookingFormHandler)
+
 	// Route for the booking handler
 	mux.HandleFunc("/booking", a.BookingHandler)
 
-	// Start the server
+	// Routes for the stored bookings: the list, and one booking by order ID
+	mux.HandleFunc("/bookings", a.BookingsHandler)
+	mux.HandleFunc("/bookings/", a.BookingDetailsHandler)
+
+	// Route for cancelling a booked transfer
+	mux.HandleFunc("/booking/cancel", a.CancelHandler)
+
+	return mux
+}
+
+// startServer serves the app in the background. The returned channel receives
+// the error that stopped the server, e.g. if the port is taken.
+func startServer(a *app) <-chan error {
+	errc := make(chan error, 1)
 	go func() {
 		log.Println("Listening on http://localhost:8020")
-		log.Fatal(http.ListenAndServe("localhost:8020", mux))
+		errc <- http.ListenAndServe("localhost:8020", a.routes())
 	}()
+	return errc
 }
@@ -1,61 +1,252 @@
 package main
 
 import (
+	"errors"
 	"html/template"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"airport-transfer-app/internal/amadeus"
 )
 
-// SearchHandler receives a query URL containing start address and airport code, queries the Amadeus Transfer Search API, and renders a new page with a list of offers, or a message if there are no offers available.
+// searchForm holds the query parameters of a search. The address is the
+// point picked on the map: the start of the transfer when going to the
+// airport, or its end when coming from the airport.
+type searchForm struct {
+	FromAirport   bool
+	Airport       string
+	AddressLine   string
+	CityName      string
+	ZipCode       string
+	CountryCode   string
+	Latitude      string
+	Longitude     string
+	StartDateTime amadeus.LocalDateTime
+	// startDateTimeSkipped is set if the date and time do not exist at
+	// the airport, because the clocks go forward over them
+	startDateTimeSkipped bool
+	Adults               int
+	ChildAges            []int
+	StopOvers            []stopOverForm
+	Flight               flightForm
+}
+
+// stopOverForm is an intermediate stop picked on the map, in the order it
+// is driven to. Wait is the waiting time at the stop in minutes.
+type stopOverForm struct {
+	AddressLine string
+	CityName    string
+	ZipCode     string
+	CountryCode string
+	Latitude    string
+	Longitude   string
+	Wait        int
+}
+
+// parseSearchForm reads the search from the query parameters of the request
+func parseSearchForm(r *http.Request) searchForm {
+	queryParams := r.URL.Query()
+	form := searchForm{
+		FromAirport: queryParams.Get("direction") == "fromAirport",
+		AddressLine: strings.TrimSpace(queryParams.Get("streetAddress") + " " + queryParams.Get("houseNumber")),
+		CityName:    queryParams.Get("city"),
+		ZipCode:     queryParams.Get("zipCode"),
+		CountryCode: queryParams.Get("countryCode"),
+		Latitude:    queryParams.Get("latitude"),
+		Longitude:   queryParams.Get("longitude"),
+	}
+	if form.FromAirport {
+		form.Airport = queryParams.Get("startLocationCode")
+	} else {
+		form.Airport = queryParams.Get("endLocationCode")
+	}
+
+	// The browser sends the wall-clock time at the airport
+	airportZone, _ := amadeus.AirportLocation(form.Airport)
+	var err error
+	form.StartDateTime, err = amadeus.ParseLocalDateTime(queryParams.Get("startDateTime"), airportZone)
+	form.startDateTimeSkipped = errors.Is(err, amadeus.ErrSkippedTime)
+
+	form.Flight = parseFlightForm(r)
+
+	// Searches without passengers, such as old bookmarks, are for one adult
+	form.Adults, _ = strconv.Atoi(queryParams.Get("adults"))
+	if form.Adults < 1 {
+		form.Adults = 1
+	}
+	for _, age := range queryParams["childAge"] {
+		n, err := strconv.Atoi(strings.TrimSpace(age))
+		if err != nil {
+			n = -1 // rejected by the validation of the search
+		}
+		form.ChildAges = append(form.ChildAges, n)
+	}
+
+	// Each stop-over parameter is repeated once per stop, in the order of the stops
+	nth := func(name string, i int) string {
+		if values := queryParams[name]; i < len(values) {
+			return strings.TrimSpace(values[i])
+		}
+		return ""
+	}
+	for i := range queryParams["stopLatitude"] {
+		wait, _ := strconv.Atoi(nth("stopWait", i))
+		form.StopOvers = append(form.StopOvers, stopOverForm{
+			AddressLine: nth("stopAddress", i),
+			CityName:    nth("stopCity", i),
+			ZipCode:     nth("stopZipCode", i),
+			CountryCode: nth("stopCountryCode", i),
+			Latitude:    nth("stopLatitude", i),
+			Longitude:   nth("stopLongitude", i),
+			Wait:        wait,
+		})
+	}
+	return form
+}
+
+// passengers returns the size of the party
+func (f searchForm) passengers() int {
+	return f.Adults + len(f.ChildAges)
+}
+
+// searchParameters turns the form into the request body of the Transfer Search API.
+// It fails if a stop-over is invalid.
+func (f searchForm) searchParameters() (amadeus.SearchParameters, error) {
+	p := amadeus.SearchParameters{StartDateTime: f.StartDateTime}
+	geoCode := f.Latitude + "," + f.Longitude
+	if f.FromAirport {
+		p.StartLocationCode = f.Airport
+		p.EndAddressLine = f.AddressLine
+		p.EndCityName = f.CityName
+		p.EndZipCode = f.ZipCode
+		p.EndCountryCode = f.CountryCode
+		p.EndGeoCode = geoCode
+	} else {
+		p.StartAddressLine = f.AddressLine
+		p.StartCityName = f.CityName
+		p.StartZipCode = f.ZipCode
+		p.StartCountryCode = f.CountryCode
+		p.StartGeoCode = geoCode
+		p.EndLocationCode = f.Airport
+	}
+
+	if f.Flight.IsSet() {
+		segment, err := f.Flight.segment()
+		if err != nil {
+			return p, err
+		}
+		if f.Flight.Arrival {
+			p.StartConnectedSegment = segment
+		} else {
+			p.EndConnectedSegment = segment
+		}
+	}
+
+	for i := 0; i < f.Adults; i++ {
+		if err := p.AddPassenger(amadeus.PassengerAdult, 0); err != nil {
+			return p, err
+		}
+	}
+	for _, age := range f.ChildAges {
+		if err := p.AddPassenger(amadeus.PassengerChild, age); err != nil {
+			return p, err
+		}
+	}
+
+	for _, stop := range f.StopOvers {
+		lat, _ := strconv.ParseFloat(stop.Latitude, 64)
+		lng, _ := strconv.ParseFloat(stop.Longitude, 64)
+		address := amadeus.Address{
+			Line:        stop.AddressLine,
+			Zip:         stop.ZipCode,
+			CountryCode: stop.CountryCode,
+			CityName:    stop.CityName,
+			Latitude:    lat,
+			Longitude:   lng,
+		}
+		// AddStopOver validates the stop-over again, and reports its position if it is invalid
+		stopOver, _ := amadeus.NewStopOver(address, time.Duration(stop.Wait)*time.Minute)
+		if err := p.AddStopOver(stopOver); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
+
+// SearchHandler receives a query URL containing an address, an airport code and the direction of travel, queries the Amadeus Transfer Search API, and renders a new page with a list of offers, or a message if there are no offers available.
 func (a *app) SearchHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Parse the query parameters from the request URL
-	queryParams := r.URL.Query()
+	form := parseSearchForm(r)
 
-	// Retrieve the query parameters and save them to local variables
-	searchParams := amadeus.SearchParameters{
-		StartAddressLine: queryParams.Get("streetAddress") + " " + queryParams.Get("houseNumber"),
-		StartCityName:    queryParams.Get("city"),
-		StartZipCode:     queryParams.Get("zipCode"),
-		StartCountryCode: queryParams.Get("countryCode"),
-		StartGeoCode:     queryParams.Get("latitude") + "," + queryParams.Get("longitude"),
-		EndLocationCode:  queryParams.Get("endLocationCode"),
-		StartDateTime:    string(queryParams.Get("startDateTime")),
-	}
-
-	// Check if any parameter (except houseNumber) is empty
-	if searchParams.EndLocationCode == "" ||
-		searchParams.StartAddressLine == " " ||
-		searchParams.StartCityName == "" ||
-		searchParams.StartZipCode == "" ||
-		searchParams.StartCountryCode == "" ||
-		searchParams.StartGeoCode == "" {
-		template.Must(template.New("incompleteAddress").Parse(incompleteAddressTemplate)).Execute(w, searchParams)
+	// Send the search page back with inline error messages if any parameter is missing or invalid
+	// (see validation.go)
+	if errs := form.validate(time.Now()); len(errs) > 0 {
+		renderHomePage(w, homePage{Search: &form, Errors: errs}, http.StatusUnprocessableEntity)
 		return
 	}
 
 	// Call the Amadeus Transfer Search API
 	// (see internal/amadeus/search.go)
-
-	response, err := a.amadeusClient.Search(searchParams)
+	var response amadeus.SearchResponse
+	searchParams, err := form.searchParameters()
+	if err == nil {
+		response, err = a.amadeusClient.SearchContext(r.Context(), searchParams)
+	}
+	if errs := formErrorsFrom(err); errs != nil {
+		renderHomePage(w, homePage{Search: &form, Errors: errs}, http.StatusUnprocessableEntity)
+		return
+	}
 	if err != nil {
-		template.Must(template.New("searchError").Parse(searchErrorTemplate)).Execute(w, struct {
-			Search amadeus.SearchParameters
-			Error  string
-		}{searchParams, err.Error()})
+		template.Must(template.New("searchError").Parse(searchErrorTemplate+errorDetailsTemplate)).Execute(w, struct {
+			Search searchForm
+			Error  errorDetails
+		}{form, describeError(err)})
 		return
 	}
 
+	// Hide offers whose vehicle is too small for the party
+	offers, hidden := offersWithSeats(response.Data, form.passengers())
+
+	// Sort and filter the offers as asked in the query parameters
+	// (see offerfilter.go)
+	filter := parseOfferFilter(r.URL.Query())
+	filter.Currency = priceCurrency(offers)
+	offers, filtered := filter.apply(offers, time.Now())
+	response.Data = offers
+	searchQuery := withoutOfferFilter(r.URL.Query())
+
 	// Parse the offer list template
-	tmpl, err := template.New("offerList").Parse(offerListTemplate)
+	tmpl, err := template.New("offerList").Funcs(templateFuncs(requestLocale(r))).Parse(offerListTemplate + flightTemplate + offerDetailsTemplate)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Render the template to the ResponseWriter
-	err = tmpl.Execute(w, response)
+	// The booking form needs the connecting flight for the booking
+	bookingQuery := ""
+	if flight := form.Flight.values(); len(flight) > 0 {
+		bookingQuery = "&" + flight.Encode()
+	}
+
+	err = tmpl.Execute(w, struct {
+		Search         searchForm
+		Hidden         int
+		BookingQuery   string
+		Filter         offerFilter
+		Filtered       int
+		SearchQuery    url.Values
+		ClearFilterURL string
+		Sorts          []struct{ Value, Label string }
+		TransferTypes  []amadeus.TransferType
+		PaymentMethods []amadeus.PaymentMethod
+		amadeus.SearchResponse
+	}{form, hidden, bookingQuery, filter, filtered, searchQuery, "/search?" + searchQuery.Encode(),
+		offerSorts, offerTransferTypes, offerPaymentMethods, response})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -63,50 +254,138 @@ func (a *app) SearchHandler(w http.ResponseWriter, r *http.Request) {
 
 }
 
+// offersWithSeats returns the offers whose vehicle seats at least the given number of passengers, and the number of offers left out.
+// Offers that do not tell the number of seats are kept.
+func offersWithSeats(offers []amadeus.Offer, passengers int) ([]amadeus.Offer, int) {
+	var fitting []amadeus.Offer
+	for _, offer := range offers {
+		if seats := offer.Vehicle.SeatCount(); seats == 0 || seats >= passengers {
+			fitting = append(fitting, offer)
+		}
+	}
+	return fitting, len(offers) - len(fitting)
+}
+
 const offerListTemplate = `
     <!DOCTYPE html>
     <html>
         <head>
             <title>Available Transfers</title>
+            <style>
+                body { font-family: sans-serif; max-width: 60em; }
+                .offer { display: flex; gap: 1em; align-items: flex-start; border: 1px solid #ccc; border-radius: 0.5em; padding: 1em; margin-bottom: 1em; }
+                .offer img.vehicle { width: 10em; object-fit: contain; }
+                .offer .details { flex: 1; }
+                .offer h2 { margin-top: 0; font-size: 1.2em; }
+                .offer table td { padding: 0.1em 1em 0.1em 0; vertical-align: top; }
+                .offer table.facts td:first-child { font-weight: bold; }
+                .offer .booking { text-align: right; }
+                .provider-logo { max-height: 1.5em; vertical-align: middle; }
+                .print-only { display: none; }
+            </style>
         </head>
         <body>
+			<h1>{{if .Search.FromAirport}}From {{.Search.Airport}} to {{.Search.AddressLine}}, {{.Search.CityName}}{{else}}From {{.Search.AddressLine}}, {{.Search.CityName}} to {{.Search.Airport}}{{end}}</h1>
+			<p>{{.Search.Adults}} adult(s){{if .Search.ChildAges}}, {{len .Search.ChildAges}} child(ren){{end}}</p>
+			{{template "flight" .Search.Flight}}
+			{{if .Search.StopOvers}}
+			<p>Via:</p>
+			<ol>
+				{{range .Search.StopOvers}}<li>{{.AddressLine}}, {{.ZipCode}} {{.CityName}} (wait {{.Wait}} min)</li>{{end}}
+			</ol>
+			{{end}}
+			<form method="get" action="/search" class="filter">
+				{{range $name, $values := .SearchQuery}}{{range $values}}<input type="hidden" name="{{$name}}" value="{{.}}">{{end}}{{end}}
+				<p>
+					<label>Sort by
+						<select name="sort">
+							{{range .Sorts}}<option value="{{.Value}}"{{if eq .Value $.Filter.Sort}} selected{{end}}>{{.Label}}</option>{{end}}
+						</select>
+					</label>
+				</p>
+				<p>
+					{{range .TransferTypes}}<label><input type="checkbox" name="transferType" value="{{.}}"{{if $.Filter.HasTransferType .}} checked{{end}}> {{transferType .}}</label> {{end}}
+				</p>
+				<p>
+					<label>Seats at least <input type="number" name="minSeats" min="1" max="99" value="{{with .Filter.MinSeats}}{{.}}{{end}}"></label>
+					<label>Bags at least <input type="number" name="minBags" min="1" max="99" value="{{with .Filter.MinBags}}{{.}}{{end}}"></label>
+					<label>Price at most <input type="number" name="maxPrice" min="0" step="0.01" value="{{.Filter.MaxPrice}}">{{with .Filter.Currency}} {{.}}{{end}}</label>
+				</p>
+				<p>
+					<label>Payment
+						<select name="paymentMethod">
+							<option value="">Any</option>
+							{{range .PaymentMethods}}<option value="{{.}}"{{if eq . $.Filter.PaymentMethod}} selected{{end}}>{{paymentMethod .}}</option>{{end}}
+						</select>
+					</label>
+					<label><input type="checkbox" name="freeCancellation" value="1"{{if .Filter.FreeCancellation}} checked{{end}}> Free cancellation now</label>
+				</p>
+				<p>
+					<button type="submit">Apply</button>
+					{{if .Filter.IsSet}}<a href="{{.ClearFilterURL}}">Clear filters</a>{{end}}
+				</p>
+			</form>
+			{{if .Filtered}}
+				<p>{{.Filtered}} offer(s) not shown because of your filters.</p>
+			{{end}}
 			{{if ne (len .Data) 0}}
-			<table>
-				{{range .Data}}
-				<tr>
-					<td>Transfer Type</td>
-					<td>{{.TransferType}}</td>
-				</tr>
-				<tr>
-					<td>Start Time</td>
-					<td>{{.Start.DateTime}}</td>
-				</tr>
-				<tr>
-					<td>Arrival Time</td>
-					<td>{{.End.DateTime}}</td>
-				</tr>
-				<tr>
-					<td>Service Provider</td>
-					<td>{{.ServiceProvider.Name}}</td>
-				</tr>
-				<tr>
-					<td>Estimated Cost</td>
-					<td>{{.Quotation.CurrencyCode}} {{.Quotation.MonetaryAmount}}</td>
-				</tr>
-					<td><button class="book" onclick="bookOffer('{{.ID}}')">Book this transfer</button></td>
-					<td></td>
-				{{end}}
-			</table>
+			{{range .Data}}
+			<div class="offer">
+				{{with .Vehicle.ImageURL}}<img class="vehicle" src="{{.}}" alt="">{{end}}
+				<div class="details">
+					<h2>{{transferType .TransferType}}{{with .Vehicle.Category}} &middot; {{vehicleCategory .}}{{end}}</h2>
+					<table class="facts">
+						<tr>
+							<td>Start Time</td>
+							<td>{{datetime .Start.DateTime}} (in {{duration .Start.DateTime.Until}})</td>
+						</tr>
+						<tr>
+							<td>Arrival Time</td>
+							<td>{{datetime .End.DateTime}}</td>
+						</tr>
+						<tr>
+							<td>Ride Duration</td>
+							<td>{{duration (.End.DateTime.Sub .Start.DateTime)}}</td>
+						</tr>
+						<tr>
+							<td>Vehicle</td>
+							<td>{{template "vehicle" .}}{{if not .Vehicle.SeatCount}}<br/>Seats not specified{{end}}</td>
+						</tr>
+						<tr>
+							<td>Service Provider</td>
+							<td>{{template "provider" .}}</td>
+						</tr>
+						{{with .MethodsOfPaymentAccepted}}
+						<tr>
+							<td>Payment</td>
+							<td>{{range $i, $m := .}}{{if $i}}, {{end}}{{paymentMethod $m}}{{end}}</td>
+						</tr>
+						{{end}}
+					</table>
+					<details>
+						<summary>Cancellation policy</summary>
+						{{template "cancellationPolicy" .}}
+					</details>
+				</div>
+				<div class="booking">
+					{{template "price" .}}
+					<button class="book" onclick="bookOffer('{{.ID}}')">Book this transfer</button>
+				</div>
+			</div>
+			{{end}}
 			{{else}}
 				<p>Sorry, there are no transfers available.</p>
 			{{end}}
+			{{if .Hidden}}
+				<p>{{.Hidden}} offer(s) not shown because the vehicle has too few seats for your party.</p>
+			{{end}}
 			<p><a href="/">New search</a></p>
 			<script>
 				function bookOffer(offerId) {
 					document.querySelectorAll(".book").forEach(function(bookButton) {
 						bookButton.disabled = true
 					})
-					var queryString = "/booking?offerId=" + offerId;
+					var queryString = "/booking/details?offerId=" + encodeURIComponent(offerId) + {{.BookingQuery}};
 					window.location.href = queryString;
 				}
 			</script>
@@ -116,26 +395,16 @@ const offerListTemplate = `
 
 `
 
-const incompleteAddressTemplate = `<html>
-<body>
-  <h1>Address data is incomplete</h1>
-  <p>Street address: {{.StartAddressLine}}</p>
-  <p>City: {{.StartCityName}}</p>
-  <p>Zip code: {{.StartZipCode}}</p>
-  <p>Country code: {{.StartCountryCode}}</p>
-  <p><a href="/">New search</a></p>
-</body>
-</html>`
-
 const searchErrorTemplate = `<html>
 <body>
   <h1>Search failed</h1>
   <p>We're sorry, but there was an error with your search.</p>
-  <p><strong>{{.Error}}</strong></p>
-  <p>Start address: {{.Search.StartAddressLine}}<br/>
-  City: {{.Search.StartCityName}}<br/>
-  Zip code: {{.Search.StartZipCode}}<br/>
-  Country code: {{.Search.StartCountryCode}}</p>
+  {{template "errorDetails" .Error}}
+  <p>{{if .Search.FromAirport}}Destination{{else}}Start{{end}} address: {{.Search.AddressLine}}<br/>
+  City: {{.Search.CityName}}<br/>
+  Zip code: {{.Search.ZipCode}}<br/>
+  Country code: {{.Search.CountryCode}}<br/>
+  Airport: {{.Search.Airport}}</p>
   <p><a href="/">New search</a></p>
 </body>
 </html>`
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"airport-transfer-app/internal/amadeus"
+)
+
+// sessionCookie is the name of the cookie that holds the session ID
+const sessionCookie = "session"
+
+// sessionLifetime is how long a session is kept after its last request
+const sessionLifetime = 24 * time.Hour
+
+// ownerCookie is the name of the cookie that identifies the browser as the
+// owner of its bookings. It outlives sessions and restarts of the app.
+const ownerCookie = "owner"
+
+// ownerLifetime is how long the browser keeps the owner cookie
+const ownerLifetime = 365 * 24 * time.Hour
+
+// sessionStore keeps the sessions of all browsers in memory.
+// Sessions are lost when the app restarts.
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+}
+
+// session is the server-side state of one browser: the token that its
+// forms must send back, the owner key of its stored bookings, and the
+// bookings it made, by offer ID.
+type session struct {
+	id        string
+	csrfToken string
+	owner     string
+	lastSeen  time.Time
+
+	mu       sync.Mutex
+	bookings map[string]*bookingAttempt
+}
+
+// bookingAttempt is a booking of an offer within a session. Its offer ID
+// and session act as idempotency key: as long as an attempt is in flight
+// or has succeeded, the offer is not booked again. done is closed when
+// the attempt has finished, after which response and err are set.
+//
+// Sessions are lost when the app restarts, so successful bookings are
+// also looked up in the bookings store by owner and offer ID (see
+// bookingstore.Store.GetByOffer). Only a booking that Amadeus confirmed
+// but that could not be stored is not recognized after a restart.
+type bookingAttempt struct {
+	done     chan struct{}
+	flight   flightForm
+	response amadeus.BookingResponse
+	err      error
+}
+
+func newSessionStore() *sessionStore {
+	return &sessionStore{sessions: map[string]*session{}}
+}
+
+// get returns the session of the request, and starts a new one if the
+// request has none or its session has expired.
+func (s *sessionStore) get(w http.ResponseWriter, r *http.Request) *session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.expire(now)
+
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		if sess, ok := s.sessions[c.Value]; ok {
+			sess.lastSeen = now
+			return sess
+		}
+	}
+
+	sess := &session{
+		id:        randomToken(),
+		csrfToken: randomToken(),
+		owner:     owner(w, r),
+		lastSeen:  now,
+		bookings:  map[string]*bookingAttempt{},
+	}
+	s.sessions[sess.id] = sess
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    sess.id,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return sess
+}
+
+// owner returns the owner key of the browser's bookings, and gives the
+// browser an owner cookie if it has none. The key is a hash of the cookie,
+// so that the bookings file does not hold anything that lets its reader
+// pose as the owner.
+func owner(w http.ResponseWriter, r *http.Request) string {
+	c, err := r.Cookie(ownerCookie)
+	if err != nil || c.Value == "" {
+		c = &http.Cookie{
+			Name:     ownerCookie,
+			Value:    randomToken(),
+			Path:     "/",
+			MaxAge:   int(ownerLifetime / time.Second),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		}
+		http.SetCookie(w, c)
+	}
+	sum := sha256.Sum256([]byte(c.Value))
+	return hex.EncodeToString(sum[:])
+}
+
+// expire removes the sessions that have not been used for sessionLifetime. s.mu must be held.
+func (s *sessionStore) expire(now time.Time) {
+	for id, sess := range s.sessions {
+		if now.Sub(sess.lastSeen) > sessionLifetime {
+			delete(s.sessions, id)
+		}
+	}
+}
+
+// validCSRFToken reports whether the form of the request carries the session's CSRF token
+func (sess *session) validCSRFToken(r *http.Request) bool {
+	token := r.PostFormValue("csrfToken")
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(sess.csrfToken)) == 1
+}
+
+// startBooking registers an attempt to book an offer. If the offer has been
+// booked already, or is being booked right now, it returns that attempt and
+// false instead. A failed attempt does not count, so the offer can be booked again.
+func (sess *session) startBooking(offerID string, flight flightForm) (*bookingAttempt, bool) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+
+	if attempt, ok := sess.bookings[offerID]; ok {
+		select {
+		case <-attempt.done:
+			if attempt.err == nil {
+				return attempt, false
+			}
+		default:
+			return attempt, false
+		}
+	}
+
+	attempt := &bookingAttempt{done: make(chan struct{}), flight: flight}
+	sess.bookings[offerID] = attempt
+	return attempt, true
+}
+
+// booking returns the successful booking of an offer, if there is one
+func (sess *session) booking(offerID string) (*bookingAttempt, bool) {
+	sess.mu.Lock()
+	attempt, ok := sess.bookings[offerID]
+	sess.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	select {
+	case <-attempt.done:
+		return attempt, attempt.err == nil
+	default:
+		return nil, false
+	}
+}
+
+// finish records the result of the attempt and wakes up anyone waiting for it
+func (attempt *bookingAttempt) finish(response amadeus.BookingResponse, err error) {
+	attempt.response = response
+	attempt.err = err
+	close(attempt.done)
+}
+
+// randomToken returns a random, URL-safe string for session IDs and CSRF tokens
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"airport-transfer-app/internal/amadeus"
+)
+
+// formErrors maps the fields of the search page to error messages.
+// The keys are the ones that home.html shows next to its inputs:
+// "address", "zipCode", "countryCode", "location", "airport",
+// "startDateTime", "flight", "passengers" and "stopOvers".
+type formErrors map[string]string
+
+// add records a message for a field. Further messages for the same field are appended.
+func (e formErrors) add(field, format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if e[field] != "" {
+		msg = e[field] + " " + msg
+	}
+	e[field] = msg
+}
+
+// airportCodePattern matches IATA airport codes, such as "CDG"
+var airportCodePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// flightNumberPattern matches IATA flight numbers: a two-character airline code, up to four digits and an optional suffix, such as "AF1234"
+var flightNumberPattern = regexp.MustCompile(`^[A-Z0-9]{2}[0-9]{1,4}[A-Z]?$`)
+
+// validate checks the search before it is sent to Amadeus. The start of the transfer
+// must be after now at the airport, so that a search made late in the evening in
+// Paris for a morning transfer in New York is not rejected.
+func (f searchForm) validate(now time.Time) formErrors {
+	errs := formErrors{}
+
+	// The address picked on the map
+	if f.AddressLine == "" || f.CityName == "" {
+		errs.add("address", "Please pick a street address with a city on the map.")
+	}
+	if !isCountryCode(f.CountryCode) {
+		errs.add("countryCode", "%q is not an ISO 3166 country code.", f.CountryCode)
+	} else if !validZipCode(f.CountryCode, f.ZipCode) {
+		errs.add("zipCode", "%q is not a valid zip code in %s.", f.ZipCode, f.CountryCode)
+	}
+	if !validCoordinates(f.Latitude, f.Longitude) {
+		errs.add("location", "Please pick a location on the map.")
+	}
+
+	// The airport and the time at the airport
+	// Without the airport's time zone, the date and time cannot be
+	// compared with now: the wall clock may be hours away from UTC.
+	zone, knownZone := amadeus.AirportLocation(f.Airport)
+	switch {
+	case !airportCodePattern.MatchString(f.Airport):
+		errs.add("airport", "Please select an airport.")
+	case !knownZone:
+		errs.add("airport", "The local time at %s is unknown. Please select another airport.", f.Airport)
+	}
+	switch {
+	case f.startDateTimeSkipped:
+		errs.add("startDateTime", "This time does not exist in %s, because the clocks go forward. Please select another time.", f.Airport)
+	case f.StartDateTime.IsZero():
+		errs.add("startDateTime", "Please select a date and time.")
+	case knownZone && !f.StartDateTime.Time().After(now):
+		errs.add("startDateTime", "The date and time must be in the future. It is now %s in %s.", now.In(zone).Format("2 Jan 2006, 15:04"), f.Airport)
+	}
+
+	if f.Flight.IsSet() {
+		if !flightNumberPattern.MatchString(f.Flight.Number) {
+			errs.add("flight", "%q is not a flight number such as AF1234.", f.Flight.Number)
+		}
+		if f.Flight.DateTime.IsZero() {
+			errs.add("flight", "Please enter the date and time of the flight.")
+		} else if knownZone && !f.Flight.DateTime.Time().After(now) {
+			errs.add("flight", "The flight must be in the future.")
+		}
+	}
+
+	if f.Adults < 1 || f.passengers() > 20 {
+		errs.add("passengers", "Please enter between 1 and 20 passengers, including at least one adult.")
+	}
+	for i, age := range f.ChildAges {
+		if age < 0 || age > 11 {
+			errs.add("passengers", "The age of child %d must be between 0 and 11.", i+1)
+		}
+	}
+
+	for i, stop := range f.StopOvers {
+		if !validCoordinates(stop.Latitude, stop.Longitude) {
+			errs.add("stopOvers", "Stop-over %d has no valid location.", i+1)
+		}
+		if stop.CountryCode != "" && !isCountryCode(stop.CountryCode) {
+			errs.add("stopOvers", "Stop-over %d is not in a known country.", i+1)
+		}
+		if stop.Wait < 0 || stop.Wait > 240 {
+			errs.add("stopOvers", "The waiting time at stop-over %d must be between 0 and 240 minutes.", i+1)
+		}
+	}
+	return errs
+}
+
+// formErrorsFrom turns the field errors that the Amadeus client found in a search into form errors.
+// It returns nil if err has no field errors.
+func formErrorsFrom(err error) formErrors {
+	fieldErrs := amadeus.FieldErrors(err)
+	if len(fieldErrs) == 0 {
+		return nil
+	}
+	errs := formErrors{}
+	for _, fe := range fieldErrs {
+		label := fieldLabel(fe.Field)
+		switch {
+		case strings.HasPrefix(fe.Field, "stopOvers"):
+			errs.add("stopOvers", "%s: %s.", label, fe.Reason)
+		case strings.HasPrefix(fe.Field, "passenger"):
+			errs.add("passengers", "%s: %s.", label, fe.Reason)
+		case strings.Contains(fe.Field, "ConnectedSegment"):
+			errs.add("flight", "%s: %s.", label, fe.Reason)
+		case strings.HasSuffix(fe.Field, "LocationCode"):
+			errs.add("airport", "%s: %s.", label, fe.Reason)
+		case fe.Field == "startDateTime":
+			errs.add("startDateTime", "%s: %s.", label, fe.Reason)
+		default:
+			errs.add("address", "%s: %s.", label, fe.Reason)
+		}
+	}
+	return errs
+}
+
+// validCoordinates reports whether a latitude and longitude are numbers in range
+func validCoordinates(latitude, longitude string) bool {
+	lat, errLat := strconv.ParseFloat(latitude, 64)
+	lng, errLng := strconv.ParseFloat(longitude, 64)
+	return errLat == nil && errLng == nil &&
+		lat >= -90 && lat <= 90 &&
+		lng >= -180 && lng <= 180
+}
+
+// isCountryCode reports whether code is an officially assigned ISO 3166-1 alpha-2 code
+func isCountryCode(code string) bool {
+	return len(code) == 2 && strings.Contains(countryCodes, " "+code+" ")
+}
+
+// countryCodes lists all officially assigned ISO 3166-1 alpha-2 codes, separated by spaces
+const countryCodes = " AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ" +
+	" BA BB BD BE BF BG BH BI BJ BL BM BN BO BQ BR BS BT BV BW BY BZ" +
+	" CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV CW CX CY CZ" +
+	" DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR" +
+	" GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY" +
+	" HK HM HN HR HT HU ID IE IL IM IN IO IQ IR IS IT JE JM JO JP" +
+	" KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK LR LS LT LU LV LY" +
+	" MA MC MD ME MF MG MH MK ML MM MN MO MP MQ MR MS MT MU MV MW MX MY MZ" +
+	" NA NC NE NF NG NI NL NO NP NR NU NZ OM" +
+	" PA PE PF PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW" +
+	" SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS ST SV SX SY SZ" +
+	" TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ" +
+	" UA UG UM US UY UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW "
+
+// zipCodePatterns are the postal code formats of the countries our customers travel to most.
+// Zip codes in other countries are only checked for being present.
+var zipCodePatterns = map[string]*regexp.Regexp{
+	"FR": regexp.MustCompile(`^\d{5}$`),
+	"MC": regexp.MustCompile(`^980\d{2}$`),
+	"DE": regexp.MustCompile(`^\d{5}$`),
+	"ES": regexp.MustCompile(`^\d{5}$`),
+	"IT": regexp.MustCompile(`^\d{5}$`),
+	"BE": regexp.MustCompile(`^\d{4}$`),
+	"LU": regexp.MustCompile(`^(L-)?\d{4}$`),
+	"CH": regexp.MustCompile(`^\d{4}$`),
+	"AT": regexp.MustCompile(`^\d{4}$`),
+	"NL": regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`),
+	"PT": regexp.MustCompile(`^\d{4}-\d{3}$`),
+	"GB": regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`),
+	"US": regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+	"CA": regexp.MustCompile(`^[A-Z]\d[A-Z] ?\d[A-Z]\d$`),
+}
+
+// validZipCode reports whether zip is a plausible postal code in the given country
+func validZipCode(countryCode, zip string) bool {
+	zip = strings.ToUpper(strings.TrimSpace(zip))
+	if zip == "" {
+		return false
+	}
+	if pattern, ok := zipCodePatterns[countryCode]; ok {
+		return pattern.MatchString(zip)
+	}
+	return true
+}